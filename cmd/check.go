@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/initialize"
+)
+
+// runCheck 检查当前代码是否与重新生成的代码完全一致，适合在CI中发现修改了表结构却没有重新生成代码的情况
+func runCheck(args []string) int {
+	fs := newFlagSet("check", "将代码生成到临时目录，与当前outPath和model目录中的代码逐字节比较，\n以unified diff格式输出每个文件的差异，存在差异时以退出码6退出，不修改任何文件。")
+	configFlags := initialize.BindConfigFlags(fs)
+	fromSnapshot := fs.String("from-snapshot", "", snapshotFlagUsage)
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	generator, err := newGenerator(configFlags, *fromSnapshot)
+	if err != nil {
+		return exitWithError(err)
+	}
+	changes, err := generator.Diff(context.Background())
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	printChanges(changes)
+	if len(changes) > 0 {
+		fmt.Println("当前代码与数据库表结构不一致，请执行 gentoolplus gen 重新生成代码")
+		return common.ExitDrift
+	}
+	return common.ExitOK
+}
@@ -0,0 +1,125 @@
+// Package cmd 实现gentoolplus的各个子命令，每个子命令有自己的命令行选项和帮助信息
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/gentoolplus"
+	"github.com/essrt/gentoolplus/initialize"
+	"github.com/essrt/gentoolplus/snapshot"
+)
+
+// command 子命令
+type command struct {
+	name    string                  // 子命令名称
+	summary string                  // 在命令列表中显示的一行说明
+	run     func(args []string) int // 执行子命令，返回进程退出码
+}
+
+// commands 所有子命令，按帮助信息中的显示顺序排列
+var commands = []command{
+	{name: "gen", summary: "根据数据库表结构生成model和query代码，并处理表关联关系", run: runGen},
+	{name: "validate", summary: "检查配置文件中的表名和关联关系是否正确，不生成代码", run: runValidate},
+	{name: "inspect", summary: "查看数据库中的表和外键关联关系", run: runInspect},
+	{name: "diff", summary: "显示重新生成的代码与当前代码的差异，不修改任何文件", run: runDiff},
+	{name: "check", summary: "检查当前代码是否与数据库表结构一致，不一致时以非0退出码退出", run: runCheck},
+	{name: "init", summary: "生成配置文件模板", run: runInit},
+	{name: "version", summary: "显示版本号", run: runVersion},
+}
+
+// Execute 根据第一个参数执行对应的子命令，返回进程退出码
+func Execute(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return common.ExitError
+	}
+
+	name := args[0]
+	switch {
+	case name == "-h" || name == "-help" || name == "--help" || name == "help":
+		printUsage()
+		return common.ExitOK
+	case strings.HasPrefix(name, "-"):
+		// 兼容不带子命令的用法：gentoolplus -c gentoolplus_config.json
+		return runGen(args)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args[1:])
+		}
+	}
+	fmt.Printf("未知的命令：%s\n\n", name)
+	printUsage()
+	return common.ExitError
+}
+
+// printUsage 显示所有子命令的帮助信息
+func printUsage() {
+	fmt.Println("用法：gentoolplus <命令> [选项]")
+	fmt.Println("命令：")
+	for _, cmd := range commands {
+		fmt.Printf("  %-10s%s\n", cmd.name, cmd.summary)
+	}
+	fmt.Println()
+	fmt.Println("使用 gentoolplus <命令> -h 查看命令的选项")
+}
+
+// newFlagSet 创建子命令的命令行选项，使用 -h 时显示子命令的用法、说明和选项
+func newFlagSet(name, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "用法：gentoolplus %s [选项]\n\n%s\n\n选项：\n", name, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析子命令的命令行选项，解析失败或者使用了 -h 时ok为false，code为进程退出码
+func parseFlags(fs *flag.FlagSet, args []string) (ok bool, code int) {
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return false, common.ExitOK
+	}
+	if err != nil {
+		return false, common.ExitError
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "无法识别的参数：%s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return false, common.ExitError
+	}
+	return true, common.ExitOK
+}
+
+// snapshotFlagUsage -from-snapshot 选项的说明
+const snapshotFlagUsage = "使用 inspect -out 保存的表结构快照文件代替数据库，不连接数据库"
+
+// newGenerator 读取配置文件并创建生成器，snapshotFile不为空时使用快照文件代替数据库
+func newGenerator(configFlags *initialize.ConfigFlags, snapshotFile string) (*gentoolplus.Generator, error) {
+	config, err := configFlags.Load()
+	if err != nil {
+		return nil, err
+	}
+	generator := gentoolplus.New(*config).UseOutput(os.Stdout)
+	if snapshotFile != "" {
+		s, err := snapshot.Load(snapshotFile)
+		if err != nil {
+			return nil, err
+		}
+		generator.UseSnapshot(s)
+	}
+	return generator, nil
+}
+
+// exitWithError 输出错误信息，并返回错误对应的进程退出码
+func exitWithError(err error) int {
+	fmt.Fprintln(os.Stderr, err)
+	return common.ExitCode(err)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/gentoolplus"
+	"github.com/essrt/gentoolplus/initialize"
+)
+
+// runDiff 显示重新生成的代码与当前代码的差异
+func runDiff(args []string) int {
+	fs := newFlagSet("diff", "将代码生成到临时目录，与当前outPath和model目录中的代码比较，\n以unified diff格式输出每个文件的差异，不修改任何文件。")
+	configFlags := initialize.BindConfigFlags(fs)
+	fromSnapshot := fs.String("from-snapshot", "", snapshotFlagUsage)
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	generator, err := newGenerator(configFlags, *fromSnapshot)
+	if err != nil {
+		return exitWithError(err)
+	}
+	changes, err := generator.Diff(context.Background())
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	printChanges(changes)
+	return common.ExitOK
+}
+
+// printChanges 输出每个文件的unified diff以及有差异的文件数量
+func printChanges(changes []gentoolplus.FileChange) {
+	for _, change := range changes {
+		fmt.Print(change.Diff)
+	}
+	if len(changes) == 0 {
+		fmt.Println("生成的代码与当前代码一致")
+	} else {
+		fmt.Printf("共 %d 个文件有差异\n", len(changes))
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/gentoolplus"
+	"github.com/essrt/gentoolplus/initialize"
+)
+
+// runGen 生成所有model和query，并处理表关联关系
+func runGen(args []string) int {
+	fs := newFlagSet("gen", "根据数据库表结构生成model和query代码，并处理表关联关系。\n生成前会先检查配置，配置错误时不同类别的错误使用不同的退出码。")
+	configFlags := initialize.BindConfigFlags(fs)
+	fromSnapshot := fs.String("from-snapshot", "", snapshotFlagUsage)
+	dryRun := fs.Bool("dry-run", false, "只显示将要新增、修改、删除的文件及其行数，不修改outPath和model目录中的任何文件")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	generator, err := newGenerator(configFlags, *fromSnapshot)
+	if err != nil {
+		return exitWithError(err)
+	}
+	if *dryRun {
+		changes, err := generator.Diff(context.Background())
+		if err != nil {
+			return exitWithError(err)
+		}
+		printPlan(changes)
+		return common.ExitOK
+	}
+	if err := generator.Run(context.Background()); err != nil {
+		return exitWithError(err)
+	}
+	return common.ExitOK
+}
+
+// fileStatusNames 文件变化类型在变更计划中显示的名称
+var fileStatusNames = map[string]string{
+	gentoolplus.FileCreated:  "新增",
+	gentoolplus.FileModified: "修改",
+	gentoolplus.FileDeleted:  "删除",
+}
+
+// printPlan 输出重新生成代码时每个文件的变化类型和新增、删除的行数
+func printPlan(changes []gentoolplus.FileChange) {
+	if len(changes) == 0 {
+		fmt.Println("生成的代码与当前代码一致，没有需要修改的文件")
+		return
+	}
+
+	width := 0
+	for _, change := range changes {
+		width = max(width, len(change.Path))
+	}
+	counts := map[string]int{}
+	fmt.Println("重新生成代码将修改以下文件：")
+	for _, change := range changes {
+		counts[change.Status]++
+		fmt.Printf("  %s  %-*s  +%d -%d\n", fileStatusNames[change.Status], width, change.Path, change.Added, change.Removed)
+	}
+	fmt.Printf("共 %d 个文件（新增 %d 个，修改 %d 个，删除 %d 个），未修改任何文件\n",
+		len(changes), counts[gentoolplus.FileCreated], counts[gentoolplus.FileModified], counts[gentoolplus.FileDeleted])
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/essrt/gentoolplus/common"
+)
+
+// runInit 生成配置文件模板
+func runInit(args []string) int {
+	fs := newFlagSet("init", "生成包含全部配置项及其默认值的配置文件模板。")
+	output := fs.String("o", "gentoolplus_config.json", "配置文件路径")
+	dbDriver := fs.String("dbDriver", "mysql", "数据库驱动（mysql、postgres、sqlite、sqlserver）")
+	force := fs.Bool("f", false, "配置文件已存在时覆盖")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if _, err := os.Stat(*output); err == nil && !*force {
+		return exitWithError(fmt.Errorf("配置文件 %s 已存在，使用 -f 覆盖", *output))
+	}
+
+	config := common.DefaultConfig()
+	config.Version = "1.0"
+	config.Database.DbDriver = *dbDriver
+	config.Database.DataMap = map[string]string{}
+	config.Database.Tables = []string{}
+	config.Database.BelongstoTables = map[string][]string{}
+	config.Database.HasoneTables = map[string][]string{}
+	config.Database.HasmanyTables = map[string][]string{}
+	config.Database.IgnoreRelations = []string{}
+	config.Database.AllowedCycles = []string{}
+	config.Database.Schemas = []string{}
+	config.Database.BidirectionalRelations = []common.BidirectionalRelation{}
+	config.Database.Many2manyTables = map[string][]string{}
+	config.Database.IgnoreJoinTables = []string{}
+	config.Database.PolymorphicRelations = []common.PolymorphicRelation{}
+	config.Database.RelationNames = map[string]string{}
+	config.Database.JoinTableModels = []string{}
+	config.Database.VirtualForeignKeys = []common.VirtualForeignKey{}
+	config.Database.SelfReferences = map[string]common.SelfReference{}
+
+	content, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return exitWithError(err)
+	}
+	if err := os.WriteFile(*output, append(content, '\n'), 0644); err != nil {
+		return exitWithError(err)
+	}
+	fmt.Println("已生成配置文件:", *output)
+	return common.ExitOK
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/initialize"
+)
+
+// runInspect 显示数据库中的表和外键关联关系，并可以保存为表结构快照文件
+func runInspect(args []string) int {
+	fs := newFlagSet("inspect", "查看数据库中的表，以及生成关联关系时使用的外键。\n使用 -out 时将表名、列（类型、是否可为null、默认值、注释）、索引和外键保存为表结构快照文件，\n之后可以通过 gen -from-snapshot 在不连接数据库的情况下生成相同的代码。")
+	configFlags := initialize.BindConfigFlags(fs)
+	out := fs.String("out", "", "表结构快照文件的保存路径")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	generator, err := newGenerator(configFlags, "")
+	if err != nil {
+		return exitWithError(err)
+	}
+	s, err := generator.Inspect(context.Background())
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	fmt.Printf("表（%d）：\n", len(s.TableNames))
+	for _, table := range s.TableNames {
+		fmt.Println("  " + table)
+	}
+	fmt.Printf("外键关联关系（%d）：\n", len(s.Relations))
+	for _, r := range s.Relations {
+		fmt.Printf("  %s.%s -> %s.%s\n", r.TABLE_NAME, r.COLUMN_NAME, r.REFERENCED_TABLE_NAME, r.REFERENCED_COLUMN_NAME)
+	}
+
+	if *out != "" {
+		if err := s.Save(*out); err != nil {
+			return exitWithError(err)
+		}
+		fmt.Printf("已保存 %d 个表的表结构快照: %s\n", len(s.Tables), *out)
+	}
+	return common.ExitOK
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/initialize"
+)
+
+// runValidate 只检查配置，不生成代码
+func runValidate(args []string) int {
+	fs := newFlagSet("validate", "检查配置文件中的表名在数据库中是否存在，hasoneTables、belongstoTables配置的表之间是否存在外键，\nmany2manyTables配置是否正确，一次列出全部配置错误，不会写入outPath。")
+	configFlags := initialize.BindConfigFlags(fs)
+	fromSnapshot := fs.String("from-snapshot", "", snapshotFlagUsage)
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	generator, err := newGenerator(configFlags, *fromSnapshot)
+	if err != nil {
+		return exitWithError(err)
+	}
+	err = generator.Validate(context.Background())
+	printValidateReport(err)
+	return common.ExitCode(err)
+}
+
+// printValidateReport 输出配置检查结果
+func printValidateReport(err error) {
+	if err == nil {
+		fmt.Println("配置检查通过")
+		return
+	}
+	var errs common.ValidationErrors
+	if !errors.As(err, &errs) {
+		fmt.Println("配置检查失败:", err)
+		return
+	}
+	for _, e := range errs {
+		fmt.Println(e)
+	}
+	fmt.Printf("配置检查未通过，共发现 %d 个配置错误\n", len(errs))
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/essrt/gentoolplus/common"
+)
+
+// Version gentoolplus的版本号，发布时可以通过 -ldflags "-X github.com/essrt/gentoolplus/cmd.Version=v1.x.x" 设置
+var Version = "v1.0.0"
+
+// runVersion 显示版本号
+func runVersion(args []string) int {
+	fs := newFlagSet("version", "显示gentoolplus的版本号。")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	fmt.Println("gentoolplus", Version)
+	return common.ExitOK
+}
@@ -14,6 +14,9 @@ type DBConfig struct {
 	OutPath  string            `json:"outPath"`
 	OutFile  string            `json:"outFile"`
 	DataMap  map[string]string `json:"dataMap"` // 自定义字段的数据类型
+	// 删除outPath和model目录中本次没有生成的*.gen.go文件（如数据库中已经删除的表），默认值 false，
+	// 多个配置共用输出目录或者只生成部分表时不要开启
+	PruneOutput bool `json:"pruneOutput"`
 
 	Tables []string `json:"tables"` // 指定要生成的表名
 
@@ -41,20 +44,111 @@ type DBConfig struct {
 	BelongstoTables map[string][]string `json:"belongstoTables"`
 	// hasone关联关系
 	HasoneTables map[string][]string `json:"hasoneTables"`
+	// hasmany关联关系，配置后只为hasoneTables、belongstoTables、hasmanyTables中的外键生成关联字段
+	HasmanyTables map[string][]string `json:"hasmanyTables"`
+	// 不生成关联字段的外键，值为外键约束名称，或者 子表名->关联表名
+	IgnoreRelations []string `json:"ignoreRelations"`
+	// 允许循环关联的关联关系，值为 表名->关联字段引用的表名，如 a->b，对应的hasone、belongsto关联字段使用指针类型
+	AllowedCycles []string `json:"allowedCycles"`
+	// 双向关联关系，同时生成子表的belongsto关联和主表的hasone或hasmany关联
+	BidirectionalRelations []BidirectionalRelation `json:"bidirectionalRelations"`
 	// many2many关联关系
 	Many2manyTables map[string][]string `json:"many2manyTables"`
+	// 自动识别many2many中间表（只有两个外键列组成主键，以及可选的时间戳列），默认值 false
+	DetectJoinTables bool `json:"detectJoinTables"`
+	// 自动识别时忽略的中间表
+	IgnoreJoinTables []string `json:"ignoreJoinTables"`
+	// 多态关联，如 comment 表通过 commentable_type、commentable_id 列关联 post、video 等多个表
+	PolymorphicRelations []PolymorphicRelation `json:"polymorphicRelations"`
+	// 需要生成连接表模型的many2many中间表，中间表中除外键以外的列（如 granted_at）可以通过连接表模型读写
+	JoinTableModels []string `json:"joinTableModels"`
+	// 虚拟外键，数据库中没有外键约束时在配置中声明表之间的外键关系，与数据库中的外键一起用于生成关联关系
+	VirtualForeignKeys []VirtualForeignKey `json:"virtualForeignKeys"`
+	// 根据列名推断表之间的关联关系，如 department_id 列引用 department 表的 id 列，默认值 false
+	InferRelations bool `json:"inferRelations"`
+	// 推断关联关系时的列名规则（正则表达式），第1个分组为关联表名，为空时使用 ^(.+)_id$
+	InferRelationPattern string `json:"inferRelationPattern"`
+	// 自关联（树形结构）表的关联字段名称，key为表名，表中有多个自关联外键时可以使用 表名.列名 分别配置
+	SelfReferences map[string]SelfReference `json:"selfReferences"`
+	// 关联字段名称的生成方式：table（默认）使用关联表名，column根据外键列名生成，并且hasmany、many2many关联字段使用复数形式
+	RelationNaming string `json:"relationNaming"`
+	// 关联字段名称，key为 表名.生成的关联字段名称，如 user.BuyerOrders，value为替换后的名称
+	RelationNames map[string]string `json:"relationNames"`
+	// 关联字段名称的复数形式转换，为nil时使用英文规则，只能在代码中设置
+	Inflector Inflector `json:"-"`
+	// 关联字段的嵌套层数，如 2 表示关联模型同样生成自己的关联字段，可以使用 Preload("Staff.Department")，默认值 1
+	RelationDepth int `json:"relationDepth"`
 	// postgres数据库中的schema名称
 	Nspname string `json:"nspname"`
+	// postgres、sqlserver数据库中需要生成的schema名称，配置后表名使用 schema名.表名 的形式，如 sales.order，关联关系可以跨schema；
+	// mysql中为需要生成的多个数据库名称，每个数据库生成到单独的model和query目录中
+	Schemas []string `json:"schemas"`
 	//	json tag 命名格式 默认为false，即与数据库表字段一致，true为使用驼峰命名
 	JsonTagFormat bool `json:"jsonTagFormat"`
 }
 
+// DefaultConfig 返回带有默认值的配置，与配置文件中未填写的配置项的默认值一致
+func DefaultConfig() ConfigFile {
+	return ConfigFile{
+		Database: DBConfig{
+			DbDriver:       "mysql",
+			OutPath:        "./dao/query",
+			OutFile:        "gen.go",
+			FieldNullable:  true,
+			FieldCoverable: true,
+			SingularTable:  true,
+			Nspname:        "public",
+			ModelPkgPath:   "model",
+			RelationDepth:  1,
+		},
+	}
+}
+
+// VirtualForeignKey 配置文件中声明的外键
+type VirtualForeignKey struct {
+	Table            string `json:"table"`            // 子表名（外键所在的表）
+	Column           string `json:"column"`           // 子表列名，为空时使用 关联表名_id
+	ReferencedTable  string `json:"referencedTable"`  // 关联表名（主表名）
+	ReferencedColumn string `json:"referencedColumn"` // 关联列名，为空时使用 id
+}
+
+// BidirectionalRelation 配置文件中声明的双向关联关系
+type BidirectionalRelation struct {
+	Table           string `json:"table"`           // 子表名（外键所在的表）
+	Column          string `json:"column"`          // 子表列名，为空时匹配子表中所有引用关联表的外键
+	ReferencedTable string `json:"referencedTable"` // 关联表名（主表名）
+	Inverse         string `json:"inverse"`         // 主表一侧的关联关系类型：hasmany（默认）或 hasone
+}
+
+// PolymorphicRelation 配置文件中声明的多态关联，在每个拥有者表的模型中生成gorm的polymorphic关联字段
+type PolymorphicRelation struct {
+	Table       string            `json:"table"`       // 多态子表名，如 comment
+	Polymorphic string            `json:"polymorphic"` // 多态列名前缀，如 commentable 对应 commentable_type、commentable_id 列，为空时使用 子表名able
+	Owners      []string          `json:"owners"`      // 拥有者表名，如 post、video
+	Values      map[string]string `json:"values"`      // 拥有者表对应的 _type 列的值，key为拥有者表名，为空时使用拥有者表名
+	HasOne      bool              `json:"hasOne"`      // 生成hasone关联，默认生成hasmany关联
+}
+
+// Inflector 将单词转换为复数形式，如 Order 转换为 Orders
+type Inflector interface {
+	Plural(word string) string
+}
+
+// SelfReference 自关联外键生成的两个关联字段的名称，为空时使用默认名称
+type SelfReference struct {
+	Parent   string `json:"parent"`   // 引用的上级记录（belongsto），默认为 Parent
+	Children string `json:"children"` // 下级记录（hasmany），默认为 Children
+}
+
 // Results 存储数据库关联关系查询结果
 type Results struct {
 	TABLE_NAME             string //子表名
 	COLUMN_NAME            string //子表列名
+	CONSTRAINT_NAME        string //外键约束名称，组合外键的每个列各有一条结果，约束名称相同
 	REFERENCED_TABLE_NAME  string //关联表名
 	REFERENCED_COLUMN_NAME string //关联列名
+	UPDATE_RULE            string //ON UPDATE规则，如 CASCADE、SET NULL、RESTRICT、NO ACTION
+	DELETE_RULE            string //ON DELETE规则
 }
 
 // SubTable 格式化后的数据库关联关系查询结果及关联关系类型
@@ -67,4 +161,13 @@ type SubTable struct {
 	REFERENCED_TABLE_NAME_UP string                 //关联表名首字母大写
 	RELATION_TYPE            field.RelationshipType //关联关系类型
 	MIDDLE_TABLE             string                 //中间表名
+	FOREIGN_KEY              string                 //关联字段gorm标签中的foreignKey，组合外键的多个字段以逗号分隔
+	REFERENCES               string                 //关联字段gorm标签中的references，为空时不生成
+	JOIN_FOREIGN_KEY         string                 //many2many关联gorm标签中的joinForeignKey，为空时不生成
+	JOIN_REFERENCES          string                 //many2many关联gorm标签中的joinReferences，为空时不生成
+	CONSTRAINT               string                 //关联字段gorm标签中的constraint，如 OnDelete:SET NULL,OnUpdate:CASCADE，为空时不生成
+	FIELD_NAME               string                 //关联字段名称，为空时使用TABLE_NAME_UP
+	POINTER                  bool                   //关联字段是否使用指针类型，自关联的belongsto字段必须使用指针
+	POLYMORPHIC              string                 //多态关联gorm标签中的polymorphic，不为空时不生成foreignKey
+	POLYMORPHIC_VALUE        string                 //多态关联gorm标签中的polymorphicValue
 }
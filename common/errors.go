@@ -0,0 +1,183 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 进程退出码，每一类配置错误对应一个退出码
+const (
+	ExitOK                = 0 // 成功
+	ExitError             = 1 // 其他错误，如数据库连接失败、生成代码失败
+	ExitUnknownTable      = 2 // 表名不存在
+	ExitMissingForeignKey = 3 // 配置的关联关系在数据库中没有对应的外键
+	ExitCyclicRelation    = 4 // 存在循环关联关系
+	ExitBadArity          = 5 // 关联关系配置的表数量不正确
+	ExitDrift             = 6 // 生成的代码与当前代码不一致（check命令）
+	ExitDuplicateField    = 7 // 同一个模型中的关联字段名称重复
+	ExitMissingColumn     = 8 // 配置的关联关系需要的列在表中不存在
+)
+
+// ValidationError 配置校验错误
+type ValidationError interface {
+	error
+	// ConfigPath 出错的配置项路径，如 database.belongstoTables.staff[1]
+	ConfigPath() string
+	// ExitCode 该类错误对应的进程退出码
+	ExitCode() int
+}
+
+// UnknownTableError 配置中的表名在数据库或tables配置项中不存在
+type UnknownTableError struct {
+	Path        string // 配置项路径
+	Table       string // 表名
+	NotInTables bool   // true表示表名不在tables配置项中，false表示表名不在数据库中
+}
+
+func (e *UnknownTableError) Error() string {
+	if e.NotInTables {
+		return fmt.Sprintf("配置文件错误：%s：表名 %s 不在tables配置项中！", e.Path, e.Table)
+	}
+	return fmt.Sprintf("配置文件错误：%s：表名 %s 不在数据库中！", e.Path, e.Table)
+}
+
+func (e *UnknownTableError) ConfigPath() string { return e.Path }
+
+func (e *UnknownTableError) ExitCode() int { return ExitUnknownTable }
+
+// MissingForeignKeyError hasone、belongsto配置的两个表之间或者joinTableModels中的中间表与关联表之间在数据库中不存在外键
+type MissingForeignKeyError struct {
+	Path            string // 配置项路径
+	Table           string // 子表名（外键所在的表）
+	ReferencedTable string // 主表名（外键引用的表）
+}
+
+func (e *MissingForeignKeyError) Error() string {
+	return fmt.Sprintf("配置文件错误：%s：表 %s 中没有引用表 %s 的外键，不存在关联关系！", e.Path, e.Table, e.ReferencedTable)
+}
+
+func (e *MissingForeignKeyError) ConfigPath() string { return e.Path }
+
+func (e *MissingForeignKeyError) ExitCode() int { return ExitMissingForeignKey }
+
+// CyclicRelationError 表之间存在循环关联关系
+type CyclicRelationError struct {
+	Path   string   // 配置项路径
+	Tables []string // 循环关联关系中的表名
+}
+
+func (e *CyclicRelationError) Error() string {
+	return fmt.Sprintf("配置文件或数据库配置错误：%s：表 %s 存在循环关联关系，确认需要循环关联时可以将其中一个关联添加到allowedCycles中！", e.Path, strings.Join(e.Tables, " -> "))
+}
+
+func (e *CyclicRelationError) ConfigPath() string { return e.Path }
+
+func (e *CyclicRelationError) ExitCode() int { return ExitCyclicRelation }
+
+// DuplicateRelationFieldError 同一个模型中生成了多个名称相同的关联字段，如一个表的两个外键引用同一个表
+type DuplicateRelationFieldError struct {
+	Path  string // 配置项路径
+	Table string // 生成关联字段的表名
+	Field string // 重复的关联字段名称
+}
+
+func (e *DuplicateRelationFieldError) Error() string {
+	return fmt.Sprintf("配置文件或数据库配置错误：%s：表 %s 中生成了多个名称为 %s 的关联字段，可以使用relationNaming或relationNames配置不同的名称！", e.Path, e.Table, e.Field)
+}
+
+func (e *DuplicateRelationFieldError) ConfigPath() string { return e.Path }
+
+func (e *DuplicateRelationFieldError) ExitCode() int { return ExitDuplicateField }
+
+// MissingColumnError 配置的关联关系需要的列在表中不存在，如多态子表中没有 前缀_type、前缀_id 列
+type MissingColumnError struct {
+	Path   string // 配置项路径
+	Table  string // 表名
+	Column string // 不存在的列名
+}
+
+func (e *MissingColumnError) Error() string {
+	return fmt.Sprintf("配置文件错误：%s：表 %s 中没有列 %s！", e.Path, e.Table, e.Column)
+}
+
+func (e *MissingColumnError) ConfigPath() string { return e.Path }
+
+func (e *MissingColumnError) ExitCode() int { return ExitMissingColumn }
+
+// BadArityError 关联关系配置的表数量不正确，如many2manyTables的value值必须是2个表
+type BadArityError struct {
+	Path     string // 配置项路径
+	Expected int    // 期望的表数量
+	Actual   int    // 实际配置的表数量
+}
+
+func (e *BadArityError) Error() string {
+	return fmt.Sprintf("配置文件错误：%s：配置项的value值必须是关联的%d个表，实际配置了%d个！", e.Path, e.Expected, e.Actual)
+}
+
+func (e *BadArityError) ConfigPath() string { return e.Path }
+
+func (e *BadArityError) ExitCode() int { return ExitBadArity }
+
+// UnknownJoinTableError joinTableModels中的表名不是many2manyTables中配置的中间表
+type UnknownJoinTableError struct {
+	Path  string // 配置项路径
+	Table string // 表名
+}
+
+func (e *UnknownJoinTableError) Error() string {
+	return fmt.Sprintf("配置文件错误：%s：表名 %s 不是many2manyTables中配置的中间表！", e.Path, e.Table)
+}
+
+func (e *UnknownJoinTableError) ConfigPath() string { return e.Path }
+
+func (e *UnknownJoinTableError) ExitCode() int { return ExitUnknownTable }
+
+// ValidationErrors 一次校验中收集到的全部配置错误
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ErrOrNil 没有收集到错误时返回nil，避免返回非nil的空切片
+func (errs ValidationErrors) ErrOrNil() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ExitCode 返回错误对应的进程退出码，包含多个配置错误时使用第一个错误的退出码
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var errs ValidationErrors
+	if errors.As(err, &errs) && len(errs) > 0 {
+		return errs[0].ExitCode()
+	}
+	var validationErr ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.ExitCode()
+	}
+	return ExitError
+}
+
+// ConfigPath 拼接配置项路径，如 ConfigPath("belongstoTables", "staff", 1) 返回 database.belongstoTables.staff[1]，
+// index小于0时不拼接下标
+func ConfigPath(section, key string, index int) string {
+	path := "database." + section
+	if key != "" {
+		path += "." + key
+	}
+	if index >= 0 {
+		path += fmt.Sprintf("[%d]", index)
+	}
+	return path
+}
@@ -0,0 +1,344 @@
+// Package ddl 将建表语句（.sql文件）加载到内存SQLite数据库中，不连接真实的数据库也可以生成代码。
+//
+// 支持CREATE TABLE、CREATE INDEX、ALTER TABLE、DROP TABLE、DROP INDEX语句，其他语句（INSERT、SET等）会被忽略。
+// MySQL建表语句中SQLite不支持的部分会在加载前转换：去掉表选项（ENGINE、CHARSET等）、AUTO_INCREMENT、
+// COMMENT、ON UPDATE CURRENT_TIMESTAMP，表内定义的KEY、INDEX转换为CREATE INDEX语句。
+// SQLite不能为已有的表添加约束，ALTER TABLE ... ADD FOREIGN KEY等语句通过重建表（表中没有数据）实现，
+// 因此通过ALTER TABLE声明的外键同样会生成关联关系；MySQL的MODIFY、CHANGE、DROP FOREIGN KEY等修改项同样通过重建表实现，
+// 其他SQLite不支持的修改项通过gorm配置中的Logger输出警告后忽略。
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open 读取path中的建表语句并加载到内存SQLite数据库中，path可以是存放.sql文件的目录，也可以是单个.sql文件，
+// 目录中的.sql文件按文件名顺序加载（与按序号命名的迁移文件的执行顺序一致）
+func Open(path string, config *gorm.Config) (*gorm.DB, error) {
+	files, err := sqlFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), config)
+	if err != nil {
+		return nil, fmt.Errorf("创建内存数据库失败: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// 每个连接都是一个独立的内存数据库，只保留一个连接，保证所有查询都能读取到加载的表结构
+	sqlDB.SetMaxOpenConns(1)
+
+	l := &loader{db: db, indexNames: map[string]bool{}}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取建表语句文件失败: %w", err)
+		}
+		for _, stmt := range splitStatements(string(content)) {
+			if err := l.exec(stmt); err != nil {
+				return nil, fmt.Errorf("执行 %s 中的建表语句失败: %w\n%s", file, err, stmt)
+			}
+		}
+	}
+	return db, nil
+}
+
+// sqlFiles 返回path中按文件名排序的.sql文件
+func sqlFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取建表语句失败: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取建表语句目录失败: %w", err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".sql") {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("目录 %s 中没有.sql文件", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loader 在内存数据库中依次执行建表语句
+type loader struct {
+	db *gorm.DB
+	// indexNames 已创建的索引名称，MySQL的索引名称只需要在表内唯一，SQLite需要在数据库内唯一
+	indexNames map[string]bool
+}
+
+// exec 执行一条语句，不影响表结构的语句直接忽略
+func (l *loader) exec(stmt string) error {
+	if m := createTableRegexp.FindStringSubmatchIndex(stmt); m != nil {
+		table := unquote(stmt[m[2]:m[3]])
+		createSQL, indexes, err := l.createTable(stmt, table, m[1]-1)
+		if err != nil {
+			return err
+		}
+		if err := l.db.Exec(createSQL).Error; err != nil {
+			return err
+		}
+		return l.execAll(indexes)
+	}
+	if m := alterTableRegexp.FindStringSubmatch(stmt); m != nil {
+		return l.alterTable(unquote(m[1]), m[2])
+	}
+	if m := createIndexRegexp.FindStringSubmatch(stmt); m != nil {
+		return l.db.Exec(l.createIndex(unquote(m[3]), []string{m[0], m[1], m[2], m[4]})).Error
+	}
+	if m := dropRegexp.FindStringSubmatch(stmt); m != nil {
+		for _, name := range splitTopLevel(m[3]) {
+			delete(l.indexNames, unquote(name))
+			dropSQL := fmt.Sprintf("DROP %s %s%s", strings.ToUpper(m[1]), strings.ToUpper(m[2]), quote(unquote(name)))
+			if err := l.db.Exec(dropSQL).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// execAll 依次执行多条语句
+func (l *loader) execAll(stmts []string) error {
+	for _, stmt := range stmts {
+		if err := l.db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTable 将CREATE TABLE语句转换为SQLite支持的语句，open为表定义左括号的位置，
+// 返回转换后的建表语句以及表内定义的索引对应的CREATE INDEX语句
+func (l *loader) createTable(stmt, table string, open int) (string, []string, error) {
+	closing := matchingParen(stmt, open)
+	if closing < 0 {
+		return "", nil, fmt.Errorf("表 %s 的定义缺少右括号", table)
+	}
+
+	items := []string{}
+	indexes := []string{}
+	for _, item := range splitTopLevel(stmt[open+1 : closing]) {
+		if m := indexRegexp.FindStringSubmatch(item); m != nil {
+			indexes = append(indexes, l.createIndex(table, m))
+		} else if constraintRegexp.MatchString(item) {
+			items = append(items, normalizeConstraint(item))
+		} else if item != "" {
+			items = append(items, normalizeColumn(item))
+		}
+	}
+	// 右括号之后的表选项（ENGINE、CHARSET等）SQLite不支持，直接去掉
+	createSQL := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", quote(table), strings.Join(items, ",\n  "))
+	return createSQL, indexes, nil
+}
+
+// alterTable 执行ALTER TABLE语句，clauses为表名之后以逗号分隔的修改项。
+// SQLite不支持的修改项（MODIFY、CHANGE、DROP FOREIGN KEY等）通过重建表实现，
+// 其他SQLite无法执行的修改项输出警告后忽略，不影响其他语句的加载
+func (l *loader) alterTable(table, clauses string) error {
+	for _, clause := range splitTopLevel(clauses) {
+		var err error
+		switch {
+		case clause == "" || tableOptionRegexp.MatchString(clause):
+			// 表选项以及OWNER TO等与表结构无关的修改项
+			continue
+		case addIndexRegexp.MatchString(clause):
+			m := indexRegexp.FindStringSubmatch(addIndexRegexp.FindStringSubmatch(clause)[1])
+			if m == nil {
+				return fmt.Errorf("无法解析的索引定义: %s", clause)
+			}
+			err = l.db.Exec(l.createIndex(table, m)).Error
+		case addConstraintRegexp.MatchString(clause):
+			// SQLite不能为已有的表添加约束，重建表
+			constraint := normalizeConstraint(addConstraintRegexp.FindStringSubmatch(clause)[1])
+			err = l.rebuildTable(table, func(items []string) ([]string, error) {
+				return append(items, constraint), nil
+			})
+		case addColumnRegexp.MatchString(clause):
+			column := normalizeColumn(columnPositionRegexp.ReplaceAllString(addColumnRegexp.FindStringSubmatch(clause)[1], ""))
+			err = l.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", quote(table), column)).Error
+		case modifyColumnRegexp.MatchString(clause):
+			column := modifyColumnRegexp.FindStringSubmatch(clause)[1]
+			err = l.modifyColumn(table, columnName(column), column)
+		case changeColumnRegexp.MatchString(clause):
+			m := changeColumnRegexp.FindStringSubmatch(clause)
+			err = l.changeColumn(table, unquote(m[1]), m[2])
+		case dropForeignKeyRegexp.MatchString(clause):
+			err = l.dropForeignKey(table, unquote(dropForeignKeyRegexp.FindStringSubmatch(clause)[1]))
+		case dropIndexRegexp.MatchString(clause):
+			err = l.dropIndex(table, unquote(dropIndexRegexp.FindStringSubmatch(clause)[1]))
+		case alterDefaultRegexp.MatchString(clause):
+			m := alterDefaultRegexp.FindStringSubmatch(clause)
+			err = l.editColumn(table, unquote(m[1]), func(column string) string {
+				column = defaultValueRegexp.ReplaceAllString(column, "")
+				if m[2] != "" {
+					column += " " + columnDefault(m[2])
+				}
+				return column
+			})
+		default:
+			// RENAME、DROP COLUMN等SQLite支持的修改项直接执行，ALTER COLUMN ... TYPE等SQLite不支持的修改项忽略
+			silent := l.db.Session(&gorm.Session{Logger: l.db.Logger.LogMode(logger.Silent)})
+			if err := silent.Exec(fmt.Sprintf("ALTER TABLE %s %s", quote(table), clause)).Error; err != nil {
+				l.warn("忽略SQLite不支持的修改项 ALTER TABLE %s %s: %v", table, clause, err)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modifyColumn 使用新的列定义替换表中名称为name的列，新的列定义中可以修改列名，
+// 与MySQL一致，表内定义的主键不受列定义修改的影响
+func (l *loader) modifyColumn(table, name, column string) error {
+	column = columnPositionRegexp.ReplaceAllString(column, "")
+	return l.editColumn(table, name, func(old string) string {
+		if primaryKeyRegexp.MatchString(old) && !primaryKeyRegexp.MatchString(column) {
+			column += " PRIMARY KEY"
+		}
+		return normalizeColumn(column)
+	})
+}
+
+// changeColumn 修改列名和列定义，先修改列名使索引和引用该列的外键同时修改，再替换列定义
+func (l *loader) changeColumn(table, name, column string) error {
+	if newName := columnName(column); newName != name {
+		if err := l.db.Exec(fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", quote(table), quote(name), quote(newName))).Error; err != nil {
+			return err
+		}
+		name = newName
+	}
+	return l.modifyColumn(table, name, column)
+}
+
+// editColumn 重建表，使用edit修改表中名称为name的列定义
+func (l *loader) editColumn(table, name string, edit func(column string) string) error {
+	return l.rebuildTable(table, func(items []string) ([]string, error) {
+		for i, item := range items {
+			if strings.EqualFold(columnName(item), name) {
+				items[i] = edit(item)
+				return items, nil
+			}
+		}
+		return nil, fmt.Errorf("表 %s 中没有列 %s", table, name)
+	})
+}
+
+// dropForeignKey 重建表，删除名称为name的外键约束，外键不存在时输出警告后忽略
+func (l *loader) dropForeignKey(table, name string) error {
+	return l.rebuildTable(table, func(items []string) ([]string, error) {
+		for i, item := range items {
+			if m := foreignKeyConstraintRegexp.FindStringSubmatch(item); m != nil && unquote(m[1]) == name {
+				return append(items[:i], items[i+1:]...), nil
+			}
+		}
+		l.warn("忽略不存在的外键 ALTER TABLE %s DROP FOREIGN KEY %s", table, name)
+		return items, nil
+	})
+}
+
+// dropIndex 删除表中的索引，索引名称与其他表重复时创建的索引名称为 表名_索引名称，索引不存在时输出警告后忽略
+func (l *loader) dropIndex(table, name string) error {
+	var names []string
+	if err := l.db.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name IN ?", table, []string{name, table + "_" + name}).Scan(&names).Error; err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		l.warn("忽略不存在的索引 ALTER TABLE %s DROP INDEX %s", table, name)
+		return nil
+	}
+	// 两个名称都存在时，名称为name的索引是这个表中的索引
+	index := names[0]
+	if len(names) > 1 {
+		index = name
+	}
+	delete(l.indexNames, index)
+	return l.db.Exec("DROP INDEX " + quote(index)).Error
+}
+
+// rebuildTable 修改已有的表：读取建表语句中的列定义和表约束，使用edit修改后删除表并重新创建，
+// 并重建表上的索引和触发器，建表语句中没有插入数据，表中没有需要迁移的数据
+func (l *loader) rebuildTable(table string, edit func(items []string) ([]string, error)) error {
+	var createSQL string
+	if err := l.db.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&createSQL).Error; err != nil {
+		return err
+	}
+	m := createTableRegexp.FindStringSubmatchIndex(createSQL)
+	if m == nil {
+		return fmt.Errorf("表 %s 不存在", table)
+	}
+	closing := matchingParen(createSQL, m[1]-1)
+	if closing < 0 {
+		return fmt.Errorf("表 %s 的定义缺少右括号", table)
+	}
+	items, err := edit(splitTopLevel(createSQL[m[1]:closing]))
+	if err != nil {
+		return err
+	}
+	others := []string{}
+	if err := l.db.Raw("SELECT sql FROM sqlite_master WHERE type IN ('index', 'trigger') AND tbl_name = ? AND sql IS NOT NULL", table).Scan(&others).Error; err != nil {
+		return err
+	}
+
+	if err := l.db.Exec("DROP TABLE " + quote(table)).Error; err != nil {
+		return err
+	}
+	if err := l.db.Exec(fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", quote(table), strings.Join(items, ",\n  "))).Error; err != nil {
+		return err
+	}
+	return l.execAll(others)
+}
+
+// warn 使用gorm配置中的Logger输出警告
+func (l *loader) warn(format string, args ...interface{}) {
+	l.db.Logger.Warn(context.Background(), format, args...)
+}
+
+// createIndex 根据indexRegexp的匹配结果返回CREATE INDEX语句，FULLTEXT、SPATIAL索引按普通索引处理
+func (l *loader) createIndex(table string, m []string) string {
+	kind, name, columns := strings.ToUpper(strings.TrimSpace(m[1])), unquote(m[2]), m[3]
+
+	if name == "" {
+		name = fmt.Sprintf("idx_%s_%d", table, len(l.indexNames))
+	}
+	if l.indexNames[name] {
+		name = table + "_" + name
+	}
+	l.indexNames[name] = true
+
+	parts := []string{}
+	for _, column := range splitTopLevel(columns) {
+		// 去掉MySQL前缀索引的长度，如 name(10)
+		parts = append(parts, prefixLengthRegexp.ReplaceAllString(column, ""))
+	}
+	unique := ""
+	if kind == "UNIQUE" {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, quote(name), quote(table), strings.Join(parts, ", "))
+}
@@ -0,0 +1,120 @@
+package ddl
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestOpenAlterTable(t *testing.T) {
+	const staff = "CREATE TABLE `staff` (\n" +
+		"  `id` int(11) NOT NULL AUTO_INCREMENT,\n" +
+		"  `name` varchar(20) DEFAULT 'a',\n" +
+		"  `company_id` int(11) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `idx_name` (`name`),\n" +
+		"  CONSTRAINT `fk_staff_company` FOREIGN KEY (`company_id`) REFERENCES `company` (`id`)\n" +
+		") ENGINE=InnoDB;\n"
+	tests := []struct {
+		name     string
+		sql      string
+		table    string   // 检查的表名，为空时为staff
+		want     []string // 表的建表语句和索引
+		warnings []string
+	}{
+		{
+			name: "MODIFY COLUMN修改列定义",
+			sql:  staff + "ALTER TABLE `staff` MODIFY COLUMN `name` varchar(64) NOT NULL DEFAULT 'x' COMMENT '名称' AFTER `id`;",
+			want: []string{
+				"CREATE TABLE \"staff\" (\n  `id` int(11) NOT NULL,\n  `name` varchar(64) NOT NULL DEFAULT 'x',\n  `company_id` int(11) DEFAULT NULL,\n" +
+					"  PRIMARY KEY (`id`),\n  CONSTRAINT `fk_staff_company` FOREIGN KEY (`company_id`) REFERENCES `company` (`id`)\n)",
+				"CREATE INDEX \"idx_name\" ON \"staff\" (`name`)",
+			},
+		},
+		{
+			name: "CHANGE COLUMN修改列名时同时修改索引和外键",
+			sql:  staff + "ALTER TABLE `staff` CHANGE `company_id` `corp_id` bigint(20) unsigned DEFAULT NULL, CHANGE COLUMN `name` `title` varchar(20);",
+			want: []string{
+				"CREATE TABLE \"staff\" (\n  `id` int(11) NOT NULL,\n  `title` varchar(20) NULL,\n  `corp_id` bigint unsigned(20) DEFAULT NULL,\n" +
+					"  PRIMARY KEY (`id`),\n  CONSTRAINT `fk_staff_company` FOREIGN KEY (\"corp_id\") REFERENCES `company` (`id`)\n)",
+				"CREATE INDEX \"idx_name\" ON \"staff\" (\"title\")",
+			},
+		},
+		{
+			name:  "MODIFY保留列上的主键",
+			sql:   "CREATE TABLE code (id varchar(10) PRIMARY KEY);\nALTER TABLE code MODIFY id varchar(20) NOT NULL;",
+			table: "code",
+			want:  []string{"CREATE TABLE \"code\" (\n  id varchar(20) NOT NULL PRIMARY KEY\n)"},
+		},
+		{
+			name: "DROP FOREIGN KEY和DROP INDEX",
+			sql: "CREATE TABLE `company` (`id` int, KEY `idx_name` (`id`));\n" + staff +
+				"ALTER TABLE `staff` DROP FOREIGN KEY `fk_staff_company`, DROP INDEX `idx_name`;",
+			want: []string{
+				"CREATE TABLE \"staff\" (\n  `id` int(11) NOT NULL,\n  `name` varchar(20) DEFAULT 'a' NULL,\n  `company_id` int(11) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n)",
+			},
+		},
+		{
+			name: "ALTER COLUMN修改和删除默认值",
+			sql:  staff + "ALTER TABLE `staff` ALTER COLUMN `name` SET DEFAULT 'b', ALTER `company_id` DROP DEFAULT, ADD COLUMN `created_at` datetime AFTER `name`, ALTER COLUMN `created_at` SET DEFAULT now();",
+			want: []string{
+				"CREATE TABLE \"staff\" (\n  `id` int(11) NOT NULL,\n  `name` varchar(20) NULL DEFAULT 'b',\n  `company_id` int(11),\n  `created_at` datetime NULL DEFAULT (now()),\n" +
+					"  PRIMARY KEY (`id`),\n  CONSTRAINT `fk_staff_company` FOREIGN KEY (`company_id`) REFERENCES `company` (`id`)\n)",
+				"CREATE INDEX \"idx_name\" ON \"staff\" (`name`)",
+			},
+		},
+		{
+			name: "忽略SQLite不支持的修改项并输出警告",
+			sql:  staff + "ALTER TABLE `staff` DROP PRIMARY KEY, DROP FOREIGN KEY `fk_nope`, DROP INDEX `idx_nope`, RENAME COLUMN `name` TO `title`;",
+			want: []string{
+				"CREATE TABLE \"staff\" (\n  `id` int(11) NOT NULL,\n  \"title\" varchar(20) DEFAULT 'a' NULL,\n  `company_id` int(11) DEFAULT NULL,\n" +
+					"  PRIMARY KEY (`id`),\n  CONSTRAINT `fk_staff_company` FOREIGN KEY (`company_id`) REFERENCES `company` (`id`)\n)",
+				"CREATE INDEX \"idx_name\" ON \"staff\" (\"title\")",
+			},
+			warnings: []string{
+				"忽略SQLite不支持的修改项 ALTER TABLE staff DROP PRIMARY KEY",
+				"忽略不存在的外键 ALTER TABLE staff DROP FOREIGN KEY fk_nope",
+				"忽略不存在的索引 ALTER TABLE staff DROP INDEX idx_nope",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schema.sql")
+			if err := os.WriteFile(path, []byte(tt.sql), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			db, err := Open(path, &gorm.Config{Logger: logger.New(log.New(&out, "", 0), logger.Config{LogLevel: logger.Warn})})
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			table := tt.table
+			if table == "" {
+				table = "staff"
+			}
+			var got []string
+			if err := db.Raw("SELECT sql FROM sqlite_master WHERE tbl_name = ? AND sql IS NOT NULL ORDER BY type DESC, name", table).Scan(&got).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Open() %s = %q, want %q", table, got, tt.want)
+			}
+			for _, warning := range tt.warnings {
+				if !strings.Contains(out.String(), warning) {
+					t.Errorf("warnings = %q, want %q", out.String(), warning)
+				}
+			}
+			if len(tt.warnings) == 0 && out.Len() > 0 {
+				t.Errorf("warnings = %q, want none", out.String())
+			}
+		})
+	}
+}
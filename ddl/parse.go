@@ -0,0 +1,236 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// createTableRegexp CREATE TABLE语句，第1组为表名，匹配结果以表定义的左括号结束
+	createTableRegexp = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMPORARY\s+|TEMP\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)\s*\(`)
+	// alterTableRegexp ALTER TABLE语句，第1组为表名，第2组为修改项
+	alterTableRegexp = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?(\S+)\s+(.*)$`)
+	// createIndexRegexp CREATE INDEX语句，分组与indexRegexp一致，第4组为表名
+	createIndexRegexp = regexp.MustCompile(`(?is)^CREATE\s+(UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\S+)\s+ON\s+(?:ONLY\s+)?(\S+)\s*(?:USING\s+\w+\s*)?\((.*)\)[^)]*$`)
+	// dropRegexp DROP TABLE、DROP INDEX语句，第1组为对象类型，第2组为IF EXISTS，第3组为对象名称
+	dropRegexp = regexp.MustCompile(`(?is)^DROP\s+(TABLE|INDEX)\s+(IF\s+EXISTS\s+)?(.+?)(?:\s+ON\s+\S+)?(?:\s+(?:CASCADE|RESTRICT))?$`)
+
+	// indexRegexp 表内定义的索引，第1组为索引类型，第2组为索引名称，第3组为索引列
+	indexRegexp = regexp.MustCompile(`(?is)^(?:CONSTRAINT\s+\S+\s+)?(UNIQUE|FULLTEXT|SPATIAL)?\s*(?:KEY|INDEX)\b\s*([^\s(]*)\s*(?:USING\s+\w+\s*)?\((.*)\)[^)]*$`)
+	// constraintRegexp 表约束
+	constraintRegexp = regexp.MustCompile(`(?is)^(?:CONSTRAINT|PRIMARY\s+KEY|FOREIGN\s+KEY|UNIQUE|CHECK)\b`)
+
+	// addIndexRegexp ALTER TABLE ... ADD INDEX，第1组为索引定义
+	addIndexRegexp = regexp.MustCompile(`(?is)^ADD\s+((?:CONSTRAINT\s+\S+\s+)?(?:(?:UNIQUE|FULLTEXT|SPATIAL)\s+)?(?:KEY|INDEX)\b.*)$`)
+	// addConstraintRegexp ALTER TABLE ... ADD CONSTRAINT，第1组为约束定义
+	addConstraintRegexp = regexp.MustCompile(`(?is)^ADD\s+((?:CONSTRAINT|PRIMARY\s+KEY|FOREIGN\s+KEY|UNIQUE|CHECK)\b.*)$`)
+	// addColumnRegexp ALTER TABLE ... ADD COLUMN，第1组为列定义
+	addColumnRegexp = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(.*)$`)
+	// modifyColumnRegexp MySQL的ALTER TABLE ... MODIFY COLUMN，第1组为新的列定义
+	modifyColumnRegexp = regexp.MustCompile(`(?is)^MODIFY\s+(?:COLUMN\s+)?(.*)$`)
+	// changeColumnRegexp MySQL的ALTER TABLE ... CHANGE COLUMN，第1组为原列名，第2组为新的列定义（可能修改列名）
+	changeColumnRegexp = regexp.MustCompile(`(?is)^CHANGE\s+(?:COLUMN\s+)?(\S+)\s+(.*)$`)
+	// dropForeignKeyRegexp MySQL的ALTER TABLE ... DROP FOREIGN KEY，第1组为外键名称
+	dropForeignKeyRegexp = regexp.MustCompile(`(?is)^DROP\s+FOREIGN\s+KEY\s+(\S+)$`)
+	// foreignKeyConstraintRegexp 带名称的外键约束，第1组为外键名称
+	foreignKeyConstraintRegexp = regexp.MustCompile(`(?is)^CONSTRAINT\s+(\S+)\s+FOREIGN\s+KEY\b`)
+	// dropIndexRegexp MySQL的ALTER TABLE ... DROP INDEX，第1组为索引名称
+	dropIndexRegexp = regexp.MustCompile(`(?is)^DROP\s+(?:INDEX|KEY)\s+(\S+)$`)
+	// alterDefaultRegexp ALTER TABLE ... ALTER COLUMN ... SET DEFAULT 或 DROP DEFAULT，第1组为列名，第2组为新的默认值，DROP DEFAULT时为空
+	alterDefaultRegexp = regexp.MustCompile(`(?is)^ALTER\s+(?:COLUMN\s+)?(\S+)\s+(?:SET\s+DEFAULT\s+(.+)|DROP\s+DEFAULT)$`)
+	// columnPositionRegexp MySQL列定义末尾的列位置，如 AFTER name，SQLite不支持
+	columnPositionRegexp = regexp.MustCompile(`(?is)\s+(?:FIRST|AFTER\s+\S+)$`)
+	// defaultValueRegexp 列定义中的默认值，用于修改或删除默认值
+	defaultValueRegexp = regexp.MustCompile(`(?is)\s+DEFAULT\s+(?:'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*"|\((?:[^()]|\([^()]*\))*\)|[^\s,]+)`)
+	// primaryKeyRegexp 列定义中的主键
+	primaryKeyRegexp = regexp.MustCompile(`(?i)\bPRIMARY\s+KEY\b`)
+	// tableOptionRegexp 与表结构无关的修改项，如表选项、所有者、自增序列
+	tableOptionRegexp = regexp.MustCompile(`(?is)^(?:(?:DEFAULT\s+)?(?:ENGINE|AUTO_INCREMENT|CHARSET|CHARACTER\s+SET|COLLATE|COMMENT|ROW_FORMAT)\b|OWNER\s+TO\b|ALTER\s+(?:COLUMN\s+)?\S+\s+SET\s+DEFAULT\s+nextval\b)`)
+
+	// columnAttrRegexp SQLite不支持的列属性
+	columnAttrRegexp = regexp.MustCompile(`(?is)\s+(?:AUTO_INCREMENT\b|COMMENT\s+(?:'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*")|ON\s+UPDATE\s+CURRENT_TIMESTAMP(?:\s*\(\s*\d*\s*\))?|(?:CHARACTER\s+SET|CHARSET)\s+\w+)`)
+	// collateRegexp 排序规则，SQLite只支持BINARY、NOCASE、RTRIM
+	collateRegexp = regexp.MustCompile(`(?i)\s+COLLATE\s+("?\w+"?)`)
+	// timestampPrecisionRegexp 带精度的CURRENT_TIMESTAMP，如 CURRENT_TIMESTAMP(3)
+	timestampPrecisionRegexp = regexp.MustCompile(`(?i)\bCURRENT_TIMESTAMP\s*\(\s*\d*\s*\)`)
+	// functionDefaultRegexp 函数调用形式的默认值，SQLite要求使用括号包裹，如 DEFAULT now()
+	functionDefaultRegexp = regexp.MustCompile(`(?i)\bDEFAULT\s+([a-z_][\w.]*\s*\((?:[^()]|\([^()]*\))*\))`)
+	// castRegexp postgres的类型转换，如 'a'::character varying
+	castRegexp = regexp.MustCompile(`::\w+(?:\s+varying)?(?:\[\])?`)
+	// enumRegexp MySQL的enum、set类型，SQLite的类型名称不支持字符串参数
+	enumRegexp = regexp.MustCompile(`(?is)^(\S+\s+)(enum|set)\s*\((?:[^()'"]|'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*")*\)`)
+	// unsignedRegexp 长度之后的UNSIGNED、ZEROFILL，SQLite要求类型名称在长度之前，如 int(11) unsigned
+	unsignedRegexp = regexp.MustCompile(`(?is)^(\S+\s+\w+)\s*(\([^)]*\))\s+((?:UNSIGNED|ZEROFILL)(?:\s+(?:UNSIGNED|ZEROFILL))?)\b`)
+	// nullRegexp 明确声明了是否可为null的列，主键列不可为null
+	nullRegexp = regexp.MustCompile(`(?i)\bNULL\b|\bPRIMARY\s+KEY\b`)
+	// referencesRegexp 带模式名的引用表，如 REFERENCES public.company
+	referencesRegexp = regexp.MustCompile(`(?i)\bREFERENCES\s+[^\s.(]+\.`)
+	// foreignKeyNameRegexp MySQL外键定义中的索引名称，如 FOREIGN KEY idx_company (company_id)
+	foreignKeyNameRegexp = regexp.MustCompile(`(?i)\bFOREIGN\s+KEY\s+[^\s(]+\s*\(`)
+	// usingRegexp 索引类型，如 USING BTREE
+	usingRegexp = regexp.MustCompile(`(?i)\s+USING\s+\w+`)
+	// prefixLengthRegexp MySQL前缀索引的长度，如 name(10)
+	prefixLengthRegexp = regexp.MustCompile(`\s*\(\d+\)`)
+)
+
+// splitStatements 按分号拆分语句并去掉注释，引号中的分号和注释符号不会被处理
+func splitStatements(text string) []string {
+	stmts := []string{}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(text, i)
+			b.WriteString(text[i : end+1])
+			i = end
+		case c == '#' || strings.HasPrefix(text[i:], "--"):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			b.WriteByte('\n')
+		case strings.HasPrefix(text[i:], "/*"):
+			// MySQL导出文件中的 /*!40101 SET ... */ 同样作为注释去掉
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				i = len(text)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		case c == ';':
+			stmts = appendStatement(stmts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return appendStatement(stmts, b.String())
+}
+
+// appendStatement 去掉语句首尾的空白，忽略空语句
+func appendStatement(stmts []string, stmt string) []string {
+	if stmt = strings.TrimSpace(stmt); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// skipQuoted 返回从s[i]开始的引号字符串的结束引号位置，没有结束引号时返回最后一个字符的位置
+func skipQuoted(s string, i int) int {
+	quote := s[i]
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == '\\' && quote != '`' {
+			j++
+		} else if s[j] == quote {
+			return j
+		}
+	}
+	return len(s) - 1
+}
+
+// splitTopLevel 按不在括号和引号中的逗号拆分，并去掉每一项首尾的空白
+func splitTopLevel(s string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"', '`':
+			i = skipQuoted(s, i)
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// matchingParen 返回与s[open]处左括号匹配的右括号位置，没有匹配的右括号时返回-1
+func matchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"', '`':
+			i = skipQuoted(s, i)
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// normalizeColumn 将列定义转换为SQLite支持的形式
+func normalizeColumn(column string) string {
+	column = columnAttrRegexp.ReplaceAllString(column, "")
+	column = collateRegexp.ReplaceAllStringFunc(column, func(s string) string {
+		switch strings.ToUpper(strings.Trim(collateRegexp.FindStringSubmatch(s)[1], `"`)) {
+		case "BINARY", "NOCASE", "RTRIM":
+			return s
+		}
+		return ""
+	})
+	column = timestampPrecisionRegexp.ReplaceAllString(column, "CURRENT_TIMESTAMP")
+	column = castRegexp.ReplaceAllString(column, "")
+	column = functionDefaultRegexp.ReplaceAllString(column, "DEFAULT ($1)")
+	column = enumRegexp.ReplaceAllString(column, "$1$2")
+	column = unsignedRegexp.ReplaceAllString(column, "$1 $3$2")
+	column = referencesRegexp.ReplaceAllString(column, "REFERENCES ")
+	// gorm的sqlite驱动读取建表语句时，没有声明NULL的列被当作不可为null，这里按照SQL标准补上NULL
+	if !nullRegexp.MatchString(column) {
+		column += " NULL"
+	}
+	return column
+}
+
+// columnDefault 将ALTER COLUMN ... SET DEFAULT中的默认值转换为SQLite支持的DEFAULT子句
+func columnDefault(value string) string {
+	clause := "DEFAULT " + strings.TrimSpace(value)
+	clause = timestampPrecisionRegexp.ReplaceAllString(clause, "CURRENT_TIMESTAMP")
+	clause = castRegexp.ReplaceAllString(clause, "")
+	return functionDefaultRegexp.ReplaceAllString(clause, "DEFAULT ($1)")
+}
+
+// columnName 返回列定义中的列名，表约束返回空字符串
+func columnName(item string) string {
+	if constraintRegexp.MatchString(item) {
+		return ""
+	}
+	fields := strings.Fields(item)
+	if len(fields) == 0 {
+		return ""
+	}
+	return unquote(fields[0])
+}
+
+// normalizeConstraint 将表约束转换为SQLite支持的形式
+func normalizeConstraint(constraint string) string {
+	constraint = foreignKeyNameRegexp.ReplaceAllString(constraint, "FOREIGN KEY (")
+	constraint = referencesRegexp.ReplaceAllString(constraint, "REFERENCES ")
+	constraint = usingRegexp.ReplaceAllString(constraint, "")
+	return columnAttrRegexp.ReplaceAllString(constraint, "")
+}
+
+// unquote 去掉名称中的引号和模式名，如 `public`.`staff` 返回 staff
+func unquote(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "`\"[]")
+}
+
+// quote 使用双引号包裹名称
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,169 @@
+package ddl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "按分号拆分并忽略空语句",
+			text: "CREATE TABLE a (id int);;\n\nDROP TABLE b;",
+			want: []string{"CREATE TABLE a (id int)", "DROP TABLE b"},
+		},
+		{
+			name: "引号中的分号不拆分",
+			text: "CREATE TABLE a (x varchar(3) DEFAULT ';', `y;z` int);INSERT INTO a VALUES ('it''s;', \"a;b\")",
+			want: []string{"CREATE TABLE a (x varchar(3) DEFAULT ';', `y;z` int)", "INSERT INTO a VALUES ('it''s;', \"a;b\")"},
+		},
+		{
+			name: "引号中的转义引号",
+			text: `INSERT INTO a VALUES ('a\';b');DROP TABLE a`,
+			want: []string{`INSERT INTO a VALUES ('a\';b')`, "DROP TABLE a"},
+		},
+		{
+			name: "去掉MySQL导出文件中的/*! */注释",
+			text: "/*!40101 SET NAMES utf8 */;\n/*!40014 SET FOREIGN_KEY_CHECKS=0 */;\nCREATE TABLE a (id int) /*!50100 PARTITION BY HASH (id) */;",
+			want: []string{"CREATE TABLE a (id int)"},
+		},
+		{
+			name: "多行注释中的分号",
+			text: "/* 注释\n; 第二行 */ DROP TABLE a",
+			want: []string{"DROP TABLE a"},
+		},
+		{
+			name: "去掉#和--注释",
+			text: "-- 注释;\nCREATE TABLE a (\n  id int, -- 主键;\n  # 名称;\n  name text\n);",
+			want: []string{"CREATE TABLE a (\n  id int, \n  \n  name text\n)"},
+		},
+		{
+			name: "引号中的注释符号不处理",
+			text: "CREATE TABLE a (x text DEFAULT '-- #', y text DEFAULT '/* */')",
+			want: []string{"CREATE TABLE a (x text DEFAULT '-- #', y text DEFAULT '/* */')"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeColumn(t *testing.T) {
+	tests := []struct {
+		name   string
+		column string
+		want   string
+	}{
+		{
+			name:   "enum去掉可选值",
+			column: "`status` enum('a','b;c','d)') NOT NULL DEFAULT 'a'",
+			want:   "`status` enum NOT NULL DEFAULT 'a'",
+		},
+		{
+			name:   "set去掉可选值",
+			column: "`tags` SET('x', 'y') DEFAULT NULL",
+			want:   "`tags` SET DEFAULT NULL",
+		},
+		{
+			name:   "unsigned移到长度之前并去掉AUTO_INCREMENT",
+			column: "`id` int(11) unsigned NOT NULL AUTO_INCREMENT",
+			want:   "`id` int unsigned(11) NOT NULL",
+		},
+		{
+			name:   "unsigned zerofill移到长度之前",
+			column: "`n` bigint(20) unsigned zerofill DEFAULT NULL",
+			want:   "`n` bigint unsigned zerofill(20) DEFAULT NULL",
+		},
+		{
+			name:   "去掉字符集、不支持的排序规则和注释",
+			column: "`name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL COMMENT 'x; y'",
+			want:   "`name` varchar(64) NOT NULL",
+		},
+		{
+			name:   "保留SQLite支持的排序规则",
+			column: "`code` varchar(10) COLLATE NOCASE",
+			want:   "`code` varchar(10) COLLATE NOCASE NULL",
+		},
+		{
+			name:   "去掉CURRENT_TIMESTAMP的精度和ON UPDATE",
+			column: "`t` timestamp(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3)",
+			want:   "`t` timestamp(3) NOT NULL DEFAULT CURRENT_TIMESTAMP",
+		},
+		{
+			name:   "去掉postgres的类型转换",
+			column: "name character varying DEFAULT 'a'::character varying",
+			want:   "name character varying DEFAULT 'a' NULL",
+		},
+		{
+			name:   "函数默认值使用括号包裹",
+			column: "created_at timestamp DEFAULT now()",
+			want:   "created_at timestamp DEFAULT (now()) NULL",
+		},
+		{
+			name:   "去掉引用表的模式名",
+			column: "company_id integer REFERENCES public.company(id)",
+			want:   "company_id integer REFERENCES company(id) NULL",
+		},
+		{
+			name:   "主键列不补NULL",
+			column: "id integer PRIMARY KEY",
+			want:   "id integer PRIMARY KEY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeColumn(tt.column); got != tt.want {
+				t.Errorf("normalizeColumn() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		item string
+		want string
+	}{
+		{item: "`company_id` int(11) DEFAULT NULL", want: "company_id"},
+		{item: `"name" varchar(20) NULL`, want: "name"},
+		{item: "age int", want: "age"},
+		{item: "PRIMARY KEY (`id`)", want: ""},
+		{item: "CONSTRAINT `fk_staff_company` FOREIGN KEY (`company_id`) REFERENCES `company` (`id`)", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.item, func(t *testing.T) {
+			if got := columnName(tt.item); got != tt.want {
+				t.Errorf("columnName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnDefault(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "字符串", value: "'a'", want: "DEFAULT 'a'"},
+		{name: "数字", value: " 18 ", want: "DEFAULT 18"},
+		{name: "函数", value: "now()", want: "DEFAULT (now())"},
+		{name: "带精度的CURRENT_TIMESTAMP", value: "CURRENT_TIMESTAMP(3)", want: "DEFAULT CURRENT_TIMESTAMP"},
+		{name: "postgres的类型转换", value: "'a'::character varying", want: "DEFAULT 'a'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := columnDefault(tt.value); got != tt.want {
+				t.Errorf("columnDefault() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
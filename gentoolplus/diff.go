@@ -0,0 +1,111 @@
+package gentoolplus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/essrt/gentoolplus/utils"
+)
+
+// 文件变化类型
+const (
+	FileCreated  = "created"  // 重新生成后新增的文件
+	FileModified = "modified" // 重新生成后内容有变化的文件
+	FileDeleted  = "deleted"  // 重新生成后不再需要的文件，只在开启pruneOutput时删除
+)
+
+// FileChange 重新生成后一个文件相对当前文件的变化
+type FileChange struct {
+	Path    string // 文件路径
+	Status  string // 变化类型：created、modified、deleted
+	Added   int    // 新增的行数
+	Removed int    // 删除的行数
+	Diff    string // unified diff格式的差异
+}
+
+// Diff 将代码生成到临时目录，与当前outPath和model目录中的文件逐个比较，返回有变化的文件，
+// 不会修改当前outPath和model目录中的任何文件
+func (g *Generator) Diff(ctx context.Context) ([]FileChange, error) {
+	stageDir, dirs, err := g.stage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stageDir)
+
+	changes := []FileChange{}
+	for _, dir := range dirs {
+		dirChanges, err := compareDir(dir.dir, dir.staged, g.config.Database.OutFile, g.config.Database.PruneOutput)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, dirChanges...)
+	}
+	return changes, nil
+}
+
+// compareDir 比较当前目录与临时目录中gen生成的文件，返回有变化的文件，prune为true时包括将被删除的文件
+func compareDir(currentDir, stagedDir, outFile string, prune bool) ([]FileChange, error) {
+	changes := []FileChange{}
+	staged := map[string]bool{}
+
+	entries, err := os.ReadDir(stagedDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !utils.IsGenFile(entry.Name(), outFile) {
+			continue
+		}
+		staged[entry.Name()] = true
+		newContent, err := os.ReadFile(filepath.Join(stagedDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		change := FileChange{Path: displayPath(filepath.Join(currentDir, entry.Name())), Status: FileModified}
+		oldContent, err := os.ReadFile(filepath.Join(currentDir, entry.Name()))
+		if os.IsNotExist(err) {
+			change.Status = FileCreated
+		} else if err != nil {
+			return nil, err
+		} else if string(oldContent) == string(newContent) {
+			continue
+		}
+		change.Diff, change.Added, change.Removed = utils.UnifiedDiff("a/"+change.Path, "b/"+change.Path, string(oldContent), string(newContent))
+		changes = append(changes, change)
+	}
+
+	if !prune {
+		return changes, nil
+	}
+	// 当前目录中存在但重新生成后不再需要的文件，如数据库中已经删除的表
+	entries, err = os.ReadDir(currentDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !utils.IsGenFile(entry.Name(), outFile) || staged[entry.Name()] {
+			continue
+		}
+		oldContent, err := os.ReadFile(filepath.Join(currentDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		change := FileChange{Path: displayPath(filepath.Join(currentDir, entry.Name())), Status: FileDeleted}
+		change.Diff, change.Added, change.Removed = utils.UnifiedDiff("a/"+change.Path, "b/"+change.Path, string(oldContent), "")
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// displayPath 返回相对当前工作目录的路径，无法计算时返回原路径
+func displayPath(path string) string {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(workDir, path); err == nil {
+		return rel
+	}
+	return path
+}
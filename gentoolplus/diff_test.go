@@ -0,0 +1,45 @@
+package gentoolplus
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompareDir(t *testing.T) {
+	tests := []struct {
+		name  string
+		prune bool
+		want  map[string]string // 文件名和变化类型
+	}{
+		{
+			name: "默认不显示本次没有生成的文件",
+			want: map[string]string{"staff.gen.go": FileModified, "dept.gen.go": FileCreated},
+		},
+		{
+			name:  "开启prune时显示将被删除的文件",
+			prune: true,
+			want:  map[string]string{"staff.gen.go": FileModified, "dept.gen.go": FileCreated, "removed.gen.go": FileDeleted},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			currentDir, stagedDir := filepath.Join(root, "query"), filepath.Join(root, "stage")
+			writeFiles(t, currentDir, map[string]string{"gen.go": "gen", "staff.gen.go": "old", "removed.gen.go": "removed", "custom.go": "custom"})
+			writeFiles(t, stagedDir, map[string]string{"gen.go": "gen", "staff.gen.go": "new", "dept.gen.go": "dept"})
+
+			changes, err := compareDir(currentDir, stagedDir, "gen.go", tt.prune)
+			if err != nil {
+				t.Fatalf("compareDir() error = %v", err)
+			}
+			got := map[string]string{}
+			for _, change := range changes {
+				got[filepath.Base(change.Path)] = change.Status
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compareDir() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
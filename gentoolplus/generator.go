@@ -0,0 +1,225 @@
+// Package gentoolplus 提供可以嵌入到其他Go程序中调用的代码生成接口，
+// 不解析命令行参数，也不依赖任何包级别的全局变量。
+package gentoolplus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/initialize"
+	"github.com/essrt/gentoolplus/process"
+	"github.com/essrt/gentoolplus/snapshot"
+	"github.com/essrt/gentoolplus/utils"
+	"gorm.io/gorm"
+)
+
+// Generator 根据一份配置生成model和query代码，同一进程中可以创建多个互不影响的实例
+type Generator struct {
+	config   common.ConfigFile
+	db       *gorm.DB
+	snapshot *snapshot.Snapshot
+	out      io.Writer
+}
+
+// New 根据配置创建生成器，配置可以通过common.DefaultConfig()获取默认值后再修改
+func New(config common.ConfigFile) *Generator {
+	return &Generator{config: config, out: io.Discard}
+}
+
+// UseOutput 设置生成进度以及推断出的关联关系、识别出的中间表的输出位置，未设置时不输出
+func (g *Generator) UseOutput(w io.Writer) *Generator {
+	g.out = w
+	return g
+}
+
+// UseDB 使用已有的数据库连接，未设置时Run会根据配置中的dbDriver和dsn建立连接
+func (g *Generator) UseDB(db *gorm.DB) *Generator {
+	g.db = db
+	return g
+}
+
+// UseSnapshot 使用表结构快照代替数据库，设置后不会连接任何数据库
+func (g *Generator) UseSnapshot(s *snapshot.Snapshot) *Generator {
+	g.snapshot = s
+	return g
+}
+
+// UseInflector 使用自定义的复数形式转换生成关联字段名称，只在relationNaming为column时使用
+func (g *Generator) UseInflector(inflector common.Inflector) *Generator {
+	g.config.Database.Inflector = inflector
+	return g
+}
+
+// Validate 检查配置中的表名和关联关系，一次返回全部配置错误（common.ValidationErrors），不生成任何文件
+func (g *Generator) Validate(ctx context.Context) error {
+	db, err := g.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	_, _, err = g.validate(db)
+	return err
+}
+
+// Run 检查配置，生成所有model和query，并处理表关联关系。代码先生成到临时目录中，全部生成成功后才替换
+// outPath和model目录中的文件，生成失败时不会修改任何文件
+func (g *Generator) Run(ctx context.Context) error {
+	stageDir, dirs, err := g.stage(ctx)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := publish(dirs, g.config.Database.OutFile, g.config.Database.PruneOutput); err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		fmt.Fprintln(g.out, "代码已写入:", dir.dir)
+	}
+	return nil
+}
+
+// Inspect 读取数据库中的表名、表结构以及表之间的外键关联关系，返回可以保存到文件的快照，
+// 使用快照时直接返回该快照
+func (g *Generator) Inspect(ctx context.Context) (*snapshot.Snapshot, error) {
+	if g.snapshot != nil {
+		return g.snapshot, nil
+	}
+	db, err := g.openDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tableNames, relationList, err := g.schema(db)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Capture(db, g.config.Database, tableNames, relationList)
+}
+
+// openDB 返回绑定了ctx的数据库连接，使用快照时返回从快照读取表结构的连接
+func (g *Generator) openDB(ctx context.Context) (*gorm.DB, error) {
+	db := g.db
+	if db == nil {
+		var err error
+		if g.snapshot != nil {
+			db, err = snapshot.Open(g.snapshot, initialize.GormConfig(g.config.Database))
+		} else {
+			db, err = initialize.InitDB(g.config.Database)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return db.WithContext(ctx), nil
+}
+
+// schema 返回数据库中所有的表名，以及表之间的外键关联关系
+func (g *Generator) schema(db *gorm.DB) ([]string, []common.Results, error) {
+	if g.snapshot != nil {
+		return g.snapshot.TableNames, g.snapshot.Relations, nil
+	}
+	tableNames, err := initialize.QueryTableNames(db, g.config.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	relationList, err := process.QueryRelations(db, g.config.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tableNames, relationList, nil
+}
+
+// validate 检查配置文件中的表名在数据库中是否存在，以及配置的关联关系是否正确，
+// 返回去掉表名空格并合并了识别出的中间表的配置，以及数据库中的外键、配置文件中的虚拟外键和根据列名推断的外键组成的关联关系
+func (g *Generator) validate(db *gorm.DB) (common.DBConfig, []common.Results, error) {
+	config := g.config.Database
+
+	tableNames, relationList, err := g.schema(db)
+	if err != nil {
+		return config, nil, err
+	}
+	errs := initialize.CheckConfigTables(&config, tableNames)
+	// 配置了schemas时，gorm只能读取默认schema中的表名，没有配置tables时生成schemas中的全部表
+	if len(config.Schemas) > 0 && len(config.Tables) == 0 {
+		config.Tables = tableNames
+	}
+	// 表名都存在时检查多态子表中的多态列
+	if len(config.PolymorphicRelations) > 0 && len(errs) == 0 {
+		polymorphicErrs, err := process.CheckPolymorphicColumns(db, config)
+		if err != nil {
+			return config, nil, err
+		}
+		errs = append(errs, polymorphicErrs...)
+	}
+	// 配置文件中声明的虚拟外键与数据库中的外键一起用于生成关联关系
+	relationList = process.MergeVirtualForeignKeys(config, relationList)
+	if config.InferRelations && len(errs) == 0 {
+		inferred, err := process.InferRelations(db, config, relationList)
+		if err != nil {
+			return config, nil, err
+		}
+		printInferredRelations(g.out, inferred)
+		relationList = append(relationList, inferred...)
+	}
+	if config.DetectJoinTables && len(errs) == 0 {
+		detected, err := process.DetectJoinTables(db, config, relationList)
+		if err != nil {
+			return config, nil, err
+		}
+		printDetectedJoinTables(g.out, config, detected)
+		config = process.MergeJoinTables(config, detected)
+	}
+	errs = append(errs, process.CheckTableRelations(config, relationList)...)
+
+	return config, relationList, errs.ErrOrNil()
+}
+
+// printInferredRelations 向w输出根据列名推断的关联关系，以及可以直接复制到virtualForeignKeys配置项中的内容
+func printInferredRelations(w io.Writer, inferred []common.Results) {
+	fmt.Fprintf(w, "根据列名推断的关联关系（%d）：\n", len(inferred))
+	fks := []common.VirtualForeignKey{}
+	for _, relation := range inferred {
+		fmt.Fprintf(w, "  %s.%s -> %s.%s\n", relation.TABLE_NAME, relation.COLUMN_NAME, relation.REFERENCED_TABLE_NAME, relation.REFERENCED_COLUMN_NAME)
+		fks = append(fks, common.VirtualForeignKey{
+			Table:            relation.TABLE_NAME,
+			Column:           relation.COLUMN_NAME,
+			ReferencedTable:  relation.REFERENCED_TABLE_NAME,
+			ReferencedColumn: relation.REFERENCED_COLUMN_NAME,
+		})
+	}
+	if len(fks) > 0 {
+		content, _ := json.MarshalIndent(map[string]any{"virtualForeignKeys": fks}, "", "    ")
+		fmt.Fprintln(w, "确认无误后可以将以下内容添加到配置文件中，并关闭inferRelations：")
+		fmt.Fprintln(w, string(content))
+	}
+}
+
+// printDetectedJoinTables 向w输出识别出的many2many中间表，以及可以直接复制到many2manyTables配置项中的内容，
+// 已经在many2manyTables中配置或者在ignoreJoinTables中忽略的中间表会单独标出
+func printDetectedJoinTables(w io.Writer, config common.DBConfig, detected map[string][]string) {
+	fmt.Fprintf(w, "识别出的many2many中间表（%d）：\n", len(detected))
+	proposed := map[string][]string{}
+	for _, table := range utils.SortedKeys(detected) {
+		line := fmt.Sprintf("  %s: %s <-> %s", table, detected[table][0], detected[table][1])
+		if configured, ok := config.Many2manyTables[table]; ok {
+			fmt.Fprintf(w, "%s（使用many2manyTables中的配置：%s）\n", line, strings.Join(configured, ", "))
+		} else if utils.ContainsValue(config.IgnoreJoinTables, table) {
+			fmt.Fprintf(w, "%s（已忽略）\n", line)
+		} else {
+			fmt.Fprintln(w, line)
+			proposed[table] = detected[table]
+		}
+	}
+	if len(proposed) > 0 {
+		content, _ := json.MarshalIndent(map[string]any{"many2manyTables": proposed}, "", "    ")
+		fmt.Fprintln(w, "确认无误后可以将以下内容添加到配置文件中，并关闭detectJoinTables：")
+		fmt.Fprintln(w, string(content))
+	}
+}
@@ -0,0 +1,59 @@
+package gentoolplus
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/essrt/gentoolplus/common"
+)
+
+func TestGeneratorOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	sql := "CREATE TABLE company (id integer PRIMARY KEY);\nCREATE TABLE staff (id integer PRIMARY KEY, company_id integer);"
+	if err := os.WriteFile(path, []byte(sql), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := common.DefaultConfig()
+	config.Database.DbDriver = "ddl"
+	config.Database.Dsn = path
+	config.Database.InferRelations = true
+
+	tests := []struct {
+		name string
+		use  bool
+		want string
+	}{
+		{name: "默认不输出"},
+		{name: "输出到指定位置", use: true, want: "根据列名推断的关联关系（1）：\n  staff.company_id -> company.id\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 同时检查没有输出到标准输出
+			stdout, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func(saved *os.File) { os.Stdout = saved }(os.Stdout)
+			os.Stdout = stdout
+
+			var out bytes.Buffer
+			generator := New(config)
+			if tt.use {
+				generator.UseOutput(&out)
+			}
+			if err := generator.Validate(context.Background()); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got := out.String(); !strings.HasPrefix(got, tt.want) || (tt.want == "") != (got == "") {
+				t.Errorf("output = %q, want prefix %q", got, tt.want)
+			}
+			if written, _ := os.ReadFile(stdout.Name()); len(written) > 0 {
+				t.Errorf("stdout = %q, want empty", written)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package gentoolplus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/essrt/gentoolplus/process"
+	"github.com/essrt/gentoolplus/utils"
+)
+
+// outputDir 正式的输出目录及其在临时目录中对应的目录
+type outputDir struct {
+	dir    string // 正式目录（outPath或model目录）
+	staged string // 临时目录中对应的目录
+}
+
+// stage 检查配置并将代码生成到os.MkdirTemp创建的临时目录中，返回临时目录以及正式目录与临时目录的对应关系，
+// 不会修改正式目录中的任何文件，生成成功时由调用方删除临时目录
+func (g *Generator) stage(ctx context.Context) (stageDir string, dirs []outputDir, err error) {
+	// gen在生成失败时会panic，这里转换为错误返回给调用方
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("生成代码失败: %v", r)
+		}
+		if err != nil && stageDir != "" {
+			os.RemoveAll(stageDir)
+		}
+	}()
+
+	db, err := g.openDB(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 检查配置文件中的表名和关联关系
+	config, relationList, err := g.validate(db)
+	if err != nil {
+		return "", nil, err
+	}
+	if err = ctx.Err(); err != nil {
+		return "", nil, err
+	}
+
+	queryDir, modelDir, err := utils.OutputDirs(config.OutPath, config.ModelPkgPath)
+	if err != nil {
+		return "", nil, err
+	}
+	if stageDir, err = os.MkdirTemp("", "gentoolplus-"); err != nil {
+		return "", nil, err
+	}
+	// mysql配置了多个schema时，每个schema生成到单独的目录中，如 ./dao/billing/query 和 ./dao/billing/model
+	if config.DbDriver == "mysql" && len(config.Schemas) > 0 {
+		for _, schema := range config.Schemas {
+			schemaQueryDir, schemaModelDir := utils.SchemaDir(queryDir, schema), utils.SchemaDir(modelDir, schema)
+			stagedQueryDir, stagedModelDir, err := stageOutputDirs(filepath.Join(stageDir, schema), schemaQueryDir, schemaModelDir)
+			if err != nil {
+				return stageDir, nil, err
+			}
+			schemaConfig := config
+			schemaConfig.OutPath = stagedQueryDir
+			schemaConfig.ModelPkgPath = stagedModelDir
+			if err = process.ProcessSchemaTables(db, schemaConfig, relationList, schema); err != nil {
+				return stageDir, nil, err
+			}
+			dirs = append(dirs, outputDir{dir: schemaQueryDir, staged: stagedQueryDir}, outputDir{dir: schemaModelDir, staged: stagedModelDir})
+		}
+		return stageDir, dirs, nil
+	}
+
+	stagedQueryDir, stagedModelDir, err := stageOutputDirs(stageDir, queryDir, modelDir)
+	if err != nil {
+		return stageDir, nil, err
+	}
+	config.OutPath = stagedQueryDir
+	config.ModelPkgPath = stagedModelDir
+
+	// 生成所有model和query，以及表之间的关联关系
+	if err = process.ProcessTables(db, config, relationList); err != nil {
+		return stageDir, nil, err
+	}
+	return stageDir, []outputDir{{dir: queryDir, staged: stagedQueryDir}, {dir: modelDir, staged: stagedModelDir}}, nil
+}
+
+// stageOutputDirs 在stageDir中创建与query目录和model目录同名的临时目录，
+// 并在临时model目录中写入go.mod，使gen在临时目录中计算出的model包导入路径与正式目录一致
+func stageOutputDirs(stageDir, queryDir, modelDir string) (stagedQueryDir, stagedModelDir string, err error) {
+	stagedQueryDir = filepath.Join(stageDir, "query", filepath.Base(queryDir))
+	stagedModelDir = filepath.Join(stageDir, "model", filepath.Base(modelDir))
+	if err = os.MkdirAll(stagedModelDir, os.ModePerm); err != nil {
+		return "", "", err
+	}
+	if importPath := utils.ImportPath(modelDir); importPath != "" {
+		err = os.WriteFile(filepath.Join(stagedModelDir, "go.mod"), []byte("module "+importPath+"\n"), 0640)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return stagedQueryDir, stagedModelDir, nil
+}
+
+// fileSwap 正式目录中一个文件的替换操作
+type fileSwap struct {
+	path       string // 正式文件路径
+	newPath    string // 新文件复制到正式目录后的临时文件路径，为空表示删除正式文件
+	backupPath string // 正式文件改名备份后的路径，为空表示正式文件不存在
+	backedUp   bool   // 正式文件是否已经改名备份
+	replaced   bool   // 新文件是否已经改名为正式文件
+}
+
+// publish 用临时目录中生成的文件替换正式目录中gen生成的文件，内容没有变化的文件保持不变，
+// prune为true时删除正式目录中本次没有生成的文件，否则只替换本次生成的文件。
+// 新文件先复制到正式目录中的隐藏文件（临时目录可能与正式目录不在同一个文件系统中，不能直接改名），
+// 全部复制成功后再将旧文件改名备份、新文件改名为正式文件，任何一步失败都会恢复原来的文件
+func publish(dirs []outputDir, outFile string, prune bool) (err error) {
+	swaps := []*fileSwap{}
+	defer func() {
+		if err != nil {
+			// 恢复失败时一并返回，调用方可以根据错误信息手动恢复 .gentoolplus-old 备份文件
+			err = errors.Join(err, rollback(swaps))
+			return
+		}
+		for _, swap := range swaps {
+			if swap.backedUp {
+				os.Remove(swap.backupPath)
+			}
+		}
+	}()
+
+	for _, dir := range dirs {
+		dirSwaps, err := prepareSwaps(dir, outFile, prune)
+		swaps = append(swaps, dirSwaps...)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, swap := range swaps {
+		if swap.backupPath != "" {
+			if err := os.Rename(swap.path, swap.backupPath); err != nil {
+				return fmt.Errorf("备份文件失败: %w", err)
+			}
+			swap.backedUp = true
+		}
+		if swap.newPath != "" {
+			if err := os.Rename(swap.newPath, swap.path); err != nil {
+				return fmt.Errorf("写入文件失败: %w", err)
+			}
+			swap.replaced = true
+		}
+	}
+	return nil
+}
+
+// prepareSwaps 将临时目录中有变化的文件复制到正式目录中的隐藏文件，返回正式目录中需要替换的文件，
+// prune为true时同时返回需要删除的文件
+func prepareSwaps(dir outputDir, outFile string, prune bool) ([]*fileSwap, error) {
+	swaps := []*fileSwap{}
+	if err := os.MkdirAll(dir.dir, os.ModePerm); err != nil {
+		return swaps, err
+	}
+
+	staged := map[string]bool{}
+	entries, err := os.ReadDir(dir.staged)
+	if err != nil && !os.IsNotExist(err) {
+		return swaps, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !utils.IsGenFile(entry.Name(), outFile) {
+			continue
+		}
+		staged[entry.Name()] = true
+		swap := &fileSwap{path: filepath.Join(dir.dir, entry.Name())}
+		content, err := os.ReadFile(filepath.Join(dir.staged, entry.Name()))
+		if err != nil {
+			return swaps, err
+		}
+		oldContent, err := os.ReadFile(swap.path)
+		if err == nil {
+			if bytes.Equal(oldContent, content) {
+				continue
+			}
+			swap.backupPath = hiddenPath(swap.path, "old")
+		} else if !os.IsNotExist(err) {
+			return swaps, err
+		}
+		swap.newPath = hiddenPath(swap.path, "new")
+		// 先加入列表，写入失败时同样会删除已经创建的隐藏文件
+		swaps = append(swaps, swap)
+		if err := os.WriteFile(swap.newPath, content, 0640); err != nil {
+			return swaps, fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+
+	if !prune {
+		return swaps, nil
+	}
+	// 正式目录中存在但重新生成后不再需要的文件，如数据库中已经删除的表
+	entries, err = os.ReadDir(dir.dir)
+	if err != nil {
+		return swaps, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !utils.IsGenFile(entry.Name(), outFile) || staged[entry.Name()] {
+			continue
+		}
+		path := filepath.Join(dir.dir, entry.Name())
+		swaps = append(swaps, &fileSwap{path: path, backupPath: hiddenPath(path, "old")})
+	}
+	return swaps, nil
+}
+
+// rollback 按相反的顺序撤销已经完成的替换操作，恢复原来的文件，并删除复制到正式目录中的隐藏文件，返回恢复失败的文件
+func rollback(swaps []*fileSwap) error {
+	errs := []error{}
+	for i := len(swaps) - 1; i >= 0; i-- {
+		swap := swaps[i]
+		if swap.replaced {
+			os.Remove(swap.path)
+		}
+		if swap.backedUp {
+			if err := os.Rename(swap.backupPath, swap.path); err != nil {
+				errs = append(errs, fmt.Errorf("恢复文件失败: %w", err))
+			}
+		}
+		if swap.newPath != "" && !swap.replaced {
+			os.Remove(swap.newPath)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hiddenPath 返回与path在同一目录中的隐藏文件路径，以.开头的文件会被go命令忽略
+func hiddenPath(path, suffix string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".gentoolplus-"+suffix)
+}
@@ -0,0 +1,132 @@
+package gentoolplus
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles 在dir中写入文件，值为空字符串的文件名表示创建目录
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if content == "" {
+			if err := os.MkdirAll(path, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFiles 读取dir中的全部文件（不包括子目录），返回文件名和内容
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(content)
+	}
+	return files
+}
+
+func TestPublish(t *testing.T) {
+	tests := []struct {
+		name                     string
+		query, model             map[string]string // 正式目录中原有的文件
+		stagedQuery, stagedModel map[string]string // 临时目录中生成的文件
+		prune                    bool
+		wantErr                  bool
+		wantQuery, wantModel     map[string]string
+	}{
+		{
+			name:        "替换有变化的文件并保留本次没有生成的文件",
+			query:       map[string]string{"gen.go": "old gen", "staff.gen.go": "old staff", "company.gen.go": "company", "custom.go": "custom"},
+			model:       map[string]string{"staff.gen.go": "old model", "other.gen.go": "other"},
+			stagedQuery: map[string]string{"gen.go": "new gen", "staff.gen.go": "new staff", "dept.gen.go": "dept"},
+			stagedModel: map[string]string{"staff.gen.go": "new model", "go.mod": "module x"},
+			wantQuery:   map[string]string{"gen.go": "new gen", "staff.gen.go": "new staff", "company.gen.go": "company", "dept.gen.go": "dept", "custom.go": "custom"},
+			wantModel:   map[string]string{"staff.gen.go": "new model", "other.gen.go": "other"},
+		},
+		{
+			name:        "开启prune时删除本次没有生成的文件",
+			query:       map[string]string{"gen.go": "old gen", "staff.gen.go": "old staff", "company.gen.go": "company", "custom.go": "custom"},
+			model:       map[string]string{"staff.gen.go": "old model", "removed.gen.go": "removed"},
+			stagedQuery: map[string]string{"gen.go": "new gen", "staff.gen.go": "new staff", "dept.gen.go": "dept"},
+			stagedModel: map[string]string{"staff.gen.go": "new model", "go.mod": "module x"},
+			prune:       true,
+			wantQuery:   map[string]string{"gen.go": "new gen", "staff.gen.go": "new staff", "dept.gen.go": "dept", "custom.go": "custom"},
+			wantModel:   map[string]string{"staff.gen.go": "new model"},
+		},
+		{
+			name:        "正式目录不存在",
+			stagedQuery: map[string]string{"gen.go": "gen"},
+			stagedModel: map[string]string{"staff.gen.go": "model"},
+			wantQuery:   map[string]string{"gen.go": "gen"},
+			wantModel:   map[string]string{"staff.gen.go": "model"},
+		},
+		{
+			// query目录中的文件已经替换，删除model目录中的文件时备份路径被同名目录占用导致改名失败
+			name:        "替换到一半失败时恢复原来的文件",
+			query:       map[string]string{"gen.go": "old gen", "staff.gen.go": "old staff", "custom.go": "custom"},
+			model:       map[string]string{"staff.gen.go": "old model", "removed.gen.go": "removed", ".removed.gen.go.gentoolplus-old/keep": "keep"},
+			stagedQuery: map[string]string{"gen.go": "new gen", "staff.gen.go": "new staff", "dept.gen.go": "dept"},
+			stagedModel: map[string]string{"staff.gen.go": "new model"},
+			prune:       true,
+			wantErr:     true,
+			wantQuery:   map[string]string{"gen.go": "old gen", "staff.gen.go": "old staff", "custom.go": "custom"},
+			wantModel:   map[string]string{"staff.gen.go": "old model", "removed.gen.go": "removed"},
+		},
+		{
+			// 复制新文件时隐藏文件路径被同名目录占用，替换还没有开始
+			name:        "复制新文件失败时不修改正式目录",
+			query:       map[string]string{"gen.go": "old gen", ".gen.go.gentoolplus-new": ""},
+			model:       map[string]string{"staff.gen.go": "old model"},
+			stagedQuery: map[string]string{"gen.go": "new gen"},
+			stagedModel: map[string]string{"staff.gen.go": "new model"},
+			wantErr:     true,
+			wantQuery:   map[string]string{"gen.go": "old gen"},
+			wantModel:   map[string]string{"staff.gen.go": "old model"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			queryDir, modelDir := filepath.Join(root, "dao", "query"), filepath.Join(root, "dao", "model")
+			stagedQueryDir, stagedModelDir := filepath.Join(root, "stage", "query"), filepath.Join(root, "stage", "model")
+			writeFiles(t, queryDir, tt.query)
+			writeFiles(t, modelDir, tt.model)
+			writeFiles(t, stagedQueryDir, tt.stagedQuery)
+			writeFiles(t, stagedModelDir, tt.stagedModel)
+
+			dirs := []outputDir{{dir: queryDir, staged: stagedQueryDir}, {dir: modelDir, staged: stagedModelDir}}
+			if err := publish(dirs, "gen.go", tt.prune); (err != nil) != tt.wantErr {
+				t.Fatalf("publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := readFiles(t, queryDir); !reflect.DeepEqual(got, tt.wantQuery) {
+				t.Errorf("query目录 = %v, want %v", got, tt.wantQuery)
+			}
+			if got := readFiles(t, modelDir); !reflect.DeepEqual(got, tt.wantModel) {
+				t.Errorf("model目录 = %v, want %v", got, tt.wantModel)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ module github.com/essrt/gentoolplus
 go 1.21.1
 
 require (
+	github.com/jinzhu/inflection v1.0.0
 	github.com/spf13/viper v1.18.0
 	gorm.io/driver/mysql v1.5.2
 	gorm.io/driver/postgres v1.4.5
@@ -26,7 +27,6 @@ require (
 	github.com/jackc/pgservicefile v0.0.0-20200714003250-2b9c44734f2b // indirect
 	github.com/jackc/pgtype v1.12.0 // indirect
 	github.com/jackc/pgx/v4 v4.17.2 // indirect
-	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/magiconair/properties v1.8.7 // indirect
 	github.com/mattn/go-sqlite3 v1.14.15 // indirect
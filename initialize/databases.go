@@ -3,9 +3,9 @@ package initialize
 import (
 	"fmt"
 	"strings"
-	"sync"
 
-	"github.com/essrt/gentoolplus/global"
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/ddl"
 	"github.com/essrt/gentoolplus/utils"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -15,142 +15,228 @@ import (
 	"gorm.io/gorm/schema"
 )
 
-var (
-	once sync.Once
-)
-
-func init() {
+// InitDB 根据配置中的数据库驱动和DSN建立数据库连接
+func InitDB(config common.DBConfig) (*gorm.DB, error) {
+	var dial gorm.Dialector
+
+	if config.DbDriver == "mysql" {
+		// 支持读取其他数据库中 schema名.表名 形式的表结构
+		dial = mysqlDialector{mysql.Open(config.Dsn).(*mysql.Dialector)}
+	} else if config.DbDriver == "postgres" {
+		// 支持读取 schema名.表名 形式的表结构和索引
+		dial = postgresDialector{postgres.Open(config.Dsn).(*postgres.Dialector)}
+	} else if config.DbDriver == "sqlite" {
+		dial = sqlite.Open(config.Dsn)
+	} else if config.DbDriver == "sqlserver" {
+		// 支持读取 schema名.表名 形式的表结构
+		dial = sqlserverDialector{sqlserver.Open(config.Dsn).(*sqlserver.Dialector)}
+	} else if config.DbDriver == "ddl" {
+		// dsn为建表语句所在的目录或.sql文件，建表语句加载到内存SQLite数据库中
+		return ddl.Open(config.Dsn, GormConfig(config))
+	} else {
+		return nil, fmt.Errorf("不支持的数据库类型: %s", config.DbDriver)
+	}
 
-	// 初始化配置文件
-	initConfig()
-	// 初始化数据库连接
-	initDB()
-	// 检查配置文件中的表名在数据库中是否存在
-	checkDbTables()
+	db, err := gorm.Open(dial, GormConfig(config))
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接失败，请检查连接配置: %w", err)
+	}
+	return db, nil
 }
 
-func initDB() {
-	once.Do(func() {
-		var err error
-		var dial gorm.Dialector = mysql.Open(*global.Dsn)
-
-		if *global.DbDriver == "mysql" {
-			dial = mysql.Open(*global.Dsn)
-		} else if *global.DbDriver == "postgres" {
-			dial = postgres.Open(*global.Dsn)
-		} else if *global.DbDriver == "sqlite" {
-			dial = sqlite.Open(*global.Dsn)
-		} else if *global.DbDriver == "sqlserver" {
-			dial = sqlserver.Open(*global.Dsn)
-		} else {
-			panic(fmt.Errorf("不支持的数据库类型: %w", err))
-		}
-
-		global.DB, err = gorm.Open(dial, &gorm.Config{
-			DisableForeignKeyConstraintWhenMigrating: true,
-			NamingStrategy: schema.NamingStrategy{
-				SingularTable: global.Config.Database.SingularTable,
-			},
-		})
-		if err != nil {
-			panic(fmt.Errorf("数据库连接失败，请检查连接配置: %w", err))
-		}
-	})
+// GormConfig 返回建立数据库连接时使用的gorm配置
+func GormConfig(config common.DBConfig) *gorm.Config {
+	return &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: config.SingularTable,
+		},
+	}
 }
 
-// checkDbTables 检查配置文件中的表名在数据库中是否存在
-func checkDbTables() {
-
-	// 配置文件中hasone、belongsto、many2many关系表名称的切片
-	configTables := []string{}
+// QueryTableNames 查询数据库中所有的表名
+func QueryTableNames(db *gorm.DB, config common.DBConfig) ([]string, error) {
 	tableNames := []string{}
-	if *global.DbDriver == "mysql" {
-		global.DB.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = ?;", *global.DbName).Scan(&tableNames)
-	} else if *global.DbDriver == "postgres" {
-		global.DB.Raw("SELECT table_name FROM information_schema.tables WHERE table_catalog = ?;", *global.DbName).Scan(&tableNames)
-	} else if *global.DbDriver == "sqlite" {
-		global.DB.Raw("SELECT name AS table_name FROM sqlite_master WHERE type = 'table';").Scan(&tableNames)
-	} else if *global.DbDriver == "sqlserver" {
-		global.DB.Raw("USE " + *global.DbName + "; SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE';").Scan(&tableNames)
+	var err error
+	if config.DbDriver == "mysql" && len(config.Schemas) > 0 {
+		// 配置了多个schema时表名使用 schema名.表名 的形式
+		err = db.Raw("SELECT CONCAT(table_schema, '.', table_name) AS table_name FROM information_schema.tables WHERE table_schema IN ? AND table_type = 'BASE TABLE';", config.Schemas).Scan(&tableNames).Error
+	} else if config.DbDriver == "mysql" {
+		err = db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = ?;", config.DbName).Scan(&tableNames).Error
+	} else if config.DbDriver == "postgres" && len(config.Schemas) > 0 {
+		// 配置了schemas时表名使用 schema名.表名 的形式
+		err = db.Raw("SELECT table_schema || '.' || table_name AS table_name FROM information_schema.tables WHERE table_catalog = ? AND table_schema IN ? AND table_type = 'BASE TABLE';", config.DbName, config.Schemas).Scan(&tableNames).Error
+	} else if config.DbDriver == "postgres" {
+		err = db.Raw("SELECT table_name FROM information_schema.tables WHERE table_catalog = ?;", config.DbName).Scan(&tableNames).Error
+	} else if config.DbDriver == "sqlite" || config.DbDriver == "ddl" {
+		err = db.Raw("SELECT name AS table_name FROM sqlite_master WHERE type = 'table';").Scan(&tableNames).Error
+	} else if config.DbDriver == "sqlserver" && len(config.Schemas) > 0 {
+		err = db.Raw("USE "+config.DbName+"; SELECT TABLE_SCHEMA + '.' + TABLE_NAME AS TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA IN ?;", config.Schemas).Scan(&tableNames).Error
+	} else if config.DbDriver == "sqlserver" {
+		err = db.Raw("USE " + config.DbName + "; SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE';").Scan(&tableNames).Error
 	} else {
-		panic(fmt.Errorf("不支持的数据库类型: %s", *global.DbDriver))
+		return nil, fmt.Errorf("不支持的数据库类型: %s", config.DbDriver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询数据库表名失败: %w", err)
 	}
+	return tableNames, nil
+}
+
+// configTable 配置文件中出现的表名及其所在的配置项路径
+type configTable struct {
+	path  string
+	table string
+}
+
+// CheckConfigTables 检查配置文件中的表名在数据库中是否存在，并去掉关联关系配置中表名的空格或者换行符，
+// 配置错误不会在第一个错误处停止，而是全部收集后返回
+func CheckConfigTables(config *common.DBConfig, tableNames []string) common.ValidationErrors {
+	errs := common.ValidationErrors{}
+
+	// 配置文件中hasone、belongsto、many2many关系表名称及其配置项路径
+	relationTables := []configTable{}
 
-	tmp := []string{}
 	// 去掉hasone关系表名称中的字符串中的空格或者换行符
-	if global.Config.Database.HasoneTables != nil {
-		newHasoneTables := make(map[string][]string)
-		for key, values := range global.Config.Database.HasoneTables {
-			key = strings.TrimSpace(key)
-			configTables = append(configTables, key)
-			tmp = append(tmp, key)
-			for _, value := range values {
-				value = strings.TrimSpace(value)
-				newHasoneTables[key] = append(newHasoneTables[key], value)
-				configTables = append(configTables, value) // 将配置文件中的表名放到切片中
-				tmp = append(tmp, value)
-			}
+	config.HasoneTables = trimRelationTables(config.HasoneTables, "hasoneTables", &relationTables)
+	// 去掉belongsto关系表名称中的字符串中的空格或者换行符
+	config.BelongstoTables = trimRelationTables(config.BelongstoTables, "belongstoTables", &relationTables)
+	// 去掉hasmany关系表名称中的字符串中的空格或者换行符
+	config.HasmanyTables = trimRelationTables(config.HasmanyTables, "hasmanyTables", &relationTables)
+
+	// 检查配置文件中的many2manyTables配置是否正确，many2manyTables配置的关联关系必须是2个表之间的关联关系
+	for _, key := range utils.SortedKeys(config.Many2manyTables) {
+		if values := config.Many2manyTables[key]; len(values) != 2 {
+			errs = append(errs, &common.BadArityError{
+				Path:     common.ConfigPath("many2manyTables", strings.TrimSpace(key), -1),
+				Expected: 2,
+				Actual:   len(values),
+			})
+		}
+	}
+	// 去掉many2many关系表名称中的字符串中的空格或者换行符
+	config.Many2manyTables = trimRelationTables(config.Many2manyTables, "many2manyTables", &relationTables)
+
+	// ignoreRelations中按表名配置的 子表名->关联表名
+	for i, value := range config.IgnoreRelations {
+		config.IgnoreRelations[i] = strings.TrimSpace(value)
+		if table, referencedTable, ok := strings.Cut(value, "->"); ok {
+			config.IgnoreRelations[i] = strings.TrimSpace(table) + "->" + strings.TrimSpace(referencedTable)
+			path := common.ConfigPath("ignoreRelations", "", i)
+			relationTables = append(relationTables,
+				configTable{path: path, table: strings.TrimSpace(table)},
+				configTable{path: path, table: strings.TrimSpace(referencedTable)})
 		}
-		global.Config.Database.HasoneTables = newHasoneTables
 	}
 
-	// 去掉belongsto关系表名称中的字符串中的空格或者换行符
-	if global.Config.Database.BelongstoTables != nil {
-		newBelongstoTables := make(map[string][]string)
-		for key, values := range global.Config.Database.BelongstoTables {
-			key = strings.TrimSpace(key)
-			configTables = append(configTables, key)
-			tmp = append(tmp, key)
-			for _, value := range values {
-				value = strings.TrimSpace(value)
-				newBelongstoTables[key] = append(newBelongstoTables[key], value)
-				configTables = append(configTables, value) // 将配置文件中的表名放到切片中
-				tmp = append(tmp, value)
-			}
+	// allowedCycles中的 表名->关联字段引用的表名
+	for i, value := range config.AllowedCycles {
+		table, referencedTable, _ := strings.Cut(value, "->")
+		config.AllowedCycles[i] = strings.TrimSpace(table) + "->" + strings.TrimSpace(referencedTable)
+		path := common.ConfigPath("allowedCycles", "", i)
+		relationTables = append(relationTables,
+			configTable{path: path, table: strings.TrimSpace(table)},
+			configTable{path: path, table: strings.TrimSpace(referencedTable)})
+	}
+
+	// 生成连接表模型的表必须是many2manyTables中配置的中间表
+	for i, table := range config.JoinTableModels {
+		table = strings.TrimSpace(table)
+		config.JoinTableModels[i] = table
+		if _, ok := config.Many2manyTables[table]; !ok {
+			errs = append(errs, &common.UnknownJoinTableError{Path: common.ConfigPath("joinTableModels", "", i), Table: table})
 		}
-		global.Config.Database.BelongstoTables = newBelongstoTables
 	}
 
-	// 去掉many2many关系表名称中的字符串中的空格或者换行符
-	if global.Config.Database.Many2manyTables != nil {
-		newMany2manyTables := make(map[string][]string)
-		for key, values := range global.Config.Database.Many2manyTables {
-			// 检查配置文件中的many2manyTables配置是否正确，many2manyTables配置的关联关系必须是2个表之间的关联关系
-			if len(values) != 2 {
-				err := fmt.Errorf("配置文件错误：many2manyTables配置错误，配置项的value值必须是关联的2个表！")
-				panic(err)
-			}
-			key = strings.TrimSpace(key)
-			configTables = append(configTables, key)
-			tmp = append(tmp, key)
-			for _, value := range values {
-				value = strings.TrimSpace(value)
-				newMany2manyTables[key] = append(newMany2manyTables[key], value)
-				configTables = append(configTables, value) // 将配置文件中的表名放到切片中
-				tmp = append(tmp, value)
-			}
+	// 去掉虚拟外键中表名和列名的空格或者换行符
+	for i := range config.VirtualForeignKeys {
+		fk := &config.VirtualForeignKeys[i]
+		fk.Table = strings.TrimSpace(fk.Table)
+		fk.Column = strings.TrimSpace(fk.Column)
+		fk.ReferencedTable = strings.TrimSpace(fk.ReferencedTable)
+		fk.ReferencedColumn = strings.TrimSpace(fk.ReferencedColumn)
+		path := common.ConfigPath("virtualForeignKeys", "", i)
+		relationTables = append(relationTables,
+			configTable{path: path + ".table", table: fk.Table},
+			configTable{path: path + ".referencedTable", table: fk.ReferencedTable})
+	}
+
+	// 去掉双向关联关系中表名和列名的空格或者换行符
+	for i := range config.BidirectionalRelations {
+		relation := &config.BidirectionalRelations[i]
+		relation.Table = strings.TrimSpace(relation.Table)
+		relation.Column = strings.TrimSpace(relation.Column)
+		relation.ReferencedTable = strings.TrimSpace(relation.ReferencedTable)
+		path := common.ConfigPath("bidirectionalRelations", "", i)
+		relationTables = append(relationTables,
+			configTable{path: path + ".table", table: relation.Table},
+			configTable{path: path + ".referencedTable", table: relation.ReferencedTable})
+	}
+
+	// 去掉多态关联中表名的空格或者换行符
+	for i := range config.PolymorphicRelations {
+		relation := &config.PolymorphicRelations[i]
+		relation.Table = strings.TrimSpace(relation.Table)
+		relation.Polymorphic = strings.TrimSpace(relation.Polymorphic)
+		path := common.ConfigPath("polymorphicRelations", "", i)
+		relationTables = append(relationTables, configTable{path: path + ".table", table: relation.Table})
+		for j, owner := range relation.Owners {
+			relation.Owners[j] = strings.TrimSpace(owner)
+			relationTables = append(relationTables, configTable{path: fmt.Sprintf("%s.owners[%d]", path, j), table: relation.Owners[j]})
 		}
-		global.Config.Database.Many2manyTables = newMany2manyTables
 	}
 
-	if len(tmp) > 0 && global.Config.Database.Tables != nil && len(global.Config.Database.Tables) > 0 {
-		for _, table := range tmp {
-			if !utils.ContainsValue(global.Config.Database.Tables, table) {
-				err := fmt.Errorf("配置文件错误：表名 %s 不在tables配置项中！", table)
-				panic(err)
+	// 自关联字段名称配置的key为 表名 或 表名.列名，配置了schemas时表名本身带有schema名
+	for _, key := range utils.SortedKeys(config.SelfReferences) {
+		table := strings.TrimSpace(key)
+		if !utils.ContainsValue(tableNames, table) && strings.Contains(table, ".") {
+			table = table[:strings.LastIndex(table, ".")]
+		}
+		relationTables = append(relationTables, configTable{path: common.ConfigPath("selfReferences", strings.TrimSpace(key), -1), table: table})
+	}
+
+	// 配置了tables时，关联关系中的表名必须包含在tables中
+	if len(config.Tables) > 0 {
+		for _, t := range relationTables {
+			if !utils.ContainsValue(config.Tables, t.table) {
+				errs = append(errs, &common.UnknownTableError{Path: t.path, Table: t.table, NotInTables: true})
 			}
 		}
 	}
 
-	if global.Config.Database.Tables != nil && len(global.Config.Database.Tables) > 0 {
-		configTables = append(configTables, global.Config.Database.Tables...)
+	configTables := relationTables
+	for i, table := range config.Tables {
+		configTables = append(configTables, configTable{path: common.ConfigPath("tables", "", i), table: table})
+	}
+	for i, table := range config.IgnoreJoinTables {
+		config.IgnoreJoinTables[i] = strings.TrimSpace(table)
+		configTables = append(configTables, configTable{path: common.ConfigPath("ignoreJoinTables", "", i), table: config.IgnoreJoinTables[i]})
 	}
 
 	// 检查配置文件中的表名是否存在在数据库中
-	for _, configTable := range configTables {
-		if !utils.ContainsValue(tableNames, configTable) {
-			err := fmt.Errorf("配置文件错误：表名 %s 不在数据库中！", configTable)
-			panic(err)
+	for _, t := range configTables {
+		if !utils.ContainsValue(tableNames, t.table) {
+			errs = append(errs, &common.UnknownTableError{Path: t.path, Table: t.table})
+		}
+	}
+	return errs
+}
+
+// trimRelationTables 去掉关联关系配置中表名的空格或者换行符，并记录每个表名所在的配置项路径
+func trimRelationTables(relations map[string][]string, section string, tables *[]configTable) map[string][]string {
+	if relations == nil {
+		return nil
+	}
+	newRelations := make(map[string][]string)
+	for _, key := range utils.SortedKeys(relations) {
+		values := relations[key]
+		key = strings.TrimSpace(key)
+		*tables = append(*tables, configTable{path: common.ConfigPath(section, key, -1), table: key})
+		for i, value := range values {
+			value = strings.TrimSpace(value)
+			newRelations[key] = append(newRelations[key], value)
+			*tables = append(*tables, configTable{path: common.ConfigPath(section, key, i), table: value}) // 将配置文件中的表名放到切片中
 		}
 	}
+	return newRelations
 }
@@ -0,0 +1,65 @@
+package initialize
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlDialector mysql驱动读取 数据库名.表名 的表结构时，会在当前数据库中查询一行数据获取列类型，
+// 表不在当前数据库中时查询失败，读取时使用mysqlMigrator切换到表所在的数据库，其他操作与mysql驱动一致
+type mysqlDialector struct {
+	*mysql.Dialector
+}
+
+func (d mysqlDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return mysqlMigrator{d.Dialector.Migrator(db).(mysql.Migrator)}
+}
+
+type mysqlMigrator struct {
+	mysql.Migrator
+}
+
+// ColumnTypes 表名为 数据库名.表名 时，在固定的一个连接中切换到表所在的数据库读取列信息，读取后切换回原来的数据库，
+// 无法切换回原来的数据库（如dsn中没有指定数据库）时关闭这个连接，不放回连接池
+func (m mysqlMigrator) ColumnTypes(value interface{}) (columnTypes []gorm.ColumnType, err error) {
+	table, ok := value.(string)
+	schemaName, _, qualified := strings.Cut(table, ".")
+	if !ok || !qualified {
+		return m.Migrator.ColumnTypes(value)
+	}
+
+	sqlDB, err := m.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	ctx := m.DB.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	tx := m.DB.Session(&gorm.Session{NewDB: true, Context: ctx})
+	tx.Statement.ConnPool = conn
+
+	var current string
+	if err := tx.Raw("SELECT IFNULL(DATABASE(), '')").Scan(&current).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Exec("USE " + tx.Statement.Quote(schemaName)).Error; err != nil {
+		return nil, err
+	}
+	defer func() {
+		if current == "" || tx.Exec("USE "+tx.Statement.Quote(current)).Error != nil {
+			// 返回driver.ErrBadConn时database/sql关闭连接，避免之后的查询使用切换过数据库的连接
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+	return m.Dialector.Migrator(tx).ColumnTypes(value)
+}
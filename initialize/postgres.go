@@ -0,0 +1,84 @@
+package initialize
+
+import (
+	"database/sql"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+)
+
+// postgresDialector postgres驱动读取索引时只按表名查询，表名为 schema名.表名 时查询不到索引，
+// 使用postgresMigrator按schema读取列信息和索引，其他操作与postgres驱动一致
+type postgresDialector struct {
+	*postgres.Dialector
+}
+
+func (d postgresDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return postgresMigrator{d.Dialector.Migrator(db).(postgres.Migrator)}
+}
+
+type postgresMigrator struct {
+	postgres.Migrator
+}
+
+// postgresIndexSql 按schema和表名查询索引的列，与postgres驱动中的查询一致，增加了schema条件
+const postgresIndexSql = `SELECT i.relname AS index_name, a.attname AS column_name, ix.indisunique AS is_unique, ix.indisprimary AS is_primary
+FROM pg_class t
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_index ix ON ix.indrelid = t.oid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+WHERE t.relkind = 'r' AND n.nspname = ? AND t.relname = ?
+ORDER BY i.relname, array_position(ix.indkey::int2[], a.attnum)`
+
+// ColumnTypes 表名为 schema名.表名 时将schema名和表名分开传给postgres驱动读取列信息，否则使用postgres驱动读取
+func (m postgresMigrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
+	table, ok := value.(string)
+	if !ok || !strings.Contains(table, ".") {
+		return m.Migrator.ColumnTypes(value)
+	}
+	_, tableName, _ := strings.Cut(table, ".")
+
+	// Table会将 schema名.表名 转换为 "schema名"."表名"，postgres驱动从中取出schema名，按schema和表名查询
+	tx := m.DB.Session(&gorm.Session{NewDB: true}).Table(table)
+	return m.Dialector.Migrator(tx).ColumnTypes(tableName)
+}
+
+// GetIndexes 表名为 schema名.表名 时按schema读取索引，否则使用postgres驱动读取
+func (m postgresMigrator) GetIndexes(value interface{}) ([]gorm.Index, error) {
+	table, ok := value.(string)
+	if !ok || !strings.Contains(table, ".") {
+		return m.Migrator.GetIndexes(value)
+	}
+	schemaName, tableName, _ := strings.Cut(table, ".")
+
+	rows := []struct {
+		IndexName  string
+		ColumnName string
+		IsUnique   bool
+		IsPrimary  bool
+	}{}
+	if err := m.DB.Raw(postgresIndexSql, schemaName, tableName).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	indexes := []gorm.Index{}
+	byName := map[string]*migrator.Index{}
+	for _, row := range rows {
+		index, ok := byName[row.IndexName]
+		if !ok {
+			index = &migrator.Index{
+				TableName:       tableName,
+				NameValue:       row.IndexName,
+				PrimaryKeyValue: sql.NullBool{Bool: row.IsPrimary, Valid: true},
+				UniqueValue:     sql.NullBool{Bool: row.IsUnique, Valid: true},
+			}
+			byName[row.IndexName] = index
+			indexes = append(indexes, index)
+		}
+		index.ColumnList = append(index.ColumnList, row.ColumnName)
+	}
+	return indexes, nil
+}
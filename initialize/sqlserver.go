@@ -0,0 +1,113 @@
+package initialize
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+)
+
+// sqlserverDialector sqlserver驱动读取表结构时只按表名查询，不区分schema，
+// 表名为 schema名.表名 时使用sqlserverMigrator按schema读取列信息，其他操作与sqlserver驱动一致
+type sqlserverDialector struct {
+	*sqlserver.Dialector
+}
+
+func (d sqlserverDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqlserverMigrator{d.Dialector.Migrator(db).(sqlserver.Migrator)}
+}
+
+type sqlserverMigrator struct {
+	sqlserver.Migrator
+}
+
+// defaultValueTrimRegexp 去掉sqlserver默认值外层的括号和引号，如 ('abc') 转换为 abc，与sqlserver驱动一致
+var defaultValueTrimRegexp = regexp.MustCompile(`^\('?([^']*)'?\)$`)
+
+// ColumnTypes 表名为 schema名.表名 时按schema读取列信息，以及主键和唯一约束，否则使用sqlserver驱动读取
+func (m sqlserverMigrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
+	table, ok := value.(string)
+	if !ok || !strings.Contains(table, ".") {
+		return m.Migrator.ColumnTypes(value)
+	}
+	schemaName, tableName, _ := strings.Cut(table, ".")
+
+	// 查询一行数据获取驱动中的列类型，Table会将 schema名.表名 转换为 [schema名].[表名]
+	rows, err := m.DB.Session(&gorm.Session{}).Table(table).Limit(1).Rows()
+	if err != nil {
+		return nil, err
+	}
+	rawColumnTypes, _ := rows.ColumnTypes()
+	rows.Close()
+
+	columns, err := m.DB.Raw("SELECT column_name, data_type, column_default, is_nullable, character_maximum_length, numeric_precision, numeric_precision_radix, numeric_scale, datetime_precision FROM INFORMATION_SCHEMA.COLUMNS WHERE table_catalog = ? AND table_schema = ? AND table_name = ? ORDER BY ordinal_position",
+		m.CurrentDatabase(), schemaName, tableName).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer columns.Close()
+
+	columnTypes := []gorm.ColumnType{}
+	for columns.Next() {
+		column := migrator.ColumnType{
+			PrimaryKeyValue: sql.NullBool{Valid: true},
+			UniqueValue:     sql.NullBool{Valid: true},
+		}
+		var datetimePrecision, radixValue sql.NullInt64
+		var nullableValue sql.NullString
+		if err := columns.Scan(&column.NameValue, &column.ColumnTypeValue, &column.DefaultValueValue, &nullableValue, &column.LengthValue,
+			&column.DecimalSizeValue, &radixValue, &column.ScaleValue, &datetimePrecision); err != nil {
+			return nil, err
+		}
+		if nullableValue.Valid {
+			column.NullableValue = sql.NullBool{Bool: strings.EqualFold(nullableValue.String, "YES"), Valid: true}
+		}
+		if datetimePrecision.Valid {
+			column.DecimalSizeValue = datetimePrecision
+		}
+		if column.DefaultValueValue.Valid {
+			for matches := defaultValueTrimRegexp.FindStringSubmatch(column.DefaultValueValue.String); len(matches) > 1; matches = defaultValueTrimRegexp.FindStringSubmatch(column.DefaultValueValue.String) {
+				column.DefaultValueValue.String = matches[1]
+			}
+		} else {
+			column.DefaultValueValue.Valid = true
+		}
+		for _, c := range rawColumnTypes {
+			if c.Name() == column.NameValue.String {
+				column.SQLColumnType = c
+				break
+			}
+		}
+		columnTypes = append(columnTypes, column)
+	}
+
+	constraints, err := m.DB.Raw("SELECT c.column_name, t.constraint_type FROM information_schema.table_constraints t JOIN information_schema.constraint_column_usage c ON c.constraint_schema = t.constraint_schema AND c.constraint_name = t.constraint_name WHERE t.constraint_type IN ('PRIMARY KEY', 'UNIQUE') AND c.table_catalog = ? AND c.table_schema = ? AND c.table_name = ?",
+		m.CurrentDatabase(), schemaName, tableName).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer constraints.Close()
+	for constraints.Next() {
+		var name, constraintType string
+		if err := constraints.Scan(&name, &constraintType); err != nil {
+			return nil, err
+		}
+		for i, c := range columnTypes {
+			column := c.(migrator.ColumnType)
+			if column.NameValue.String != name {
+				continue
+			}
+			if constraintType == "PRIMARY KEY" {
+				column.PrimaryKeyValue = sql.NullBool{Bool: true, Valid: true}
+			} else {
+				column.UniqueValue = sql.NullBool{Bool: true, Valid: true}
+			}
+			columnTypes[i] = column
+			break
+		}
+	}
+	return columnTypes, nil
+}
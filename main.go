@@ -1,15 +1,12 @@
 package main
 
 import (
-	"github.com/essrt/gentoolplus/process"
+	"os"
 
-	_ "github.com/essrt/gentoolplus/initialize"
+	"github.com/essrt/gentoolplus/cmd"
 )
 
 func main() {
-
-	// 生成所有model和query
-	process.ProcessAllTables()
-	// 处理表关联关系
-	process.ProcessTableRelations()
+	// 根据子命令执行对应的操作，退出码由子命令决定
+	os.Exit(cmd.Execute(os.Args[1:]))
 }
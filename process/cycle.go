@@ -0,0 +1,107 @@
+package process
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/utils"
+	"gorm.io/gen/field"
+)
+
+// relationGraph 表之间的关联关系图，key为表名，value为该表的模型中以值类型包含的关联字段引用的表名
+type relationGraph map[string][]string
+
+/**
+ * 根据主表Map生成关联关系图，只有值类型的hasone、belongsto关联字段会使结构体互相包含，
+ * hasmany、many2many关联字段是切片，自关联、bidirectionalRelations中hasone一侧的belongsto以及allowedCycles中允许的关联字段是指针，
+ * 都不会形成无限递归的类型，不作为循环关联关系检查
+ */
+func buildRelationGraph(masterTableMap map[string][]common.SubTable) relationGraph {
+	graph := relationGraph{}
+	for _, masterTable := range utils.SortedKeys(masterTableMap) {
+		for _, subTable := range masterTableMap[masterTable] {
+			table := subTable.TABLE_NAME
+			if !valueRelation(subTable) || utils.ContainsValue(graph[masterTable], table) {
+				continue
+			}
+			graph[masterTable] = append(graph[masterTable], table)
+		}
+	}
+	for _, table := range utils.SortedKeys(graph) {
+		sort.Strings(graph[table])
+	}
+	return graph
+}
+
+// valueRelation 判断关联字段是否以值类型包含关联表的模型，即非指针的hasone、belongsto关联字段
+func valueRelation(subTable common.SubTable) bool {
+	return (subTable.RELATION_TYPE == field.HasOne || subTable.RELATION_TYPE == field.BelongsTo) && !subTable.POINTER
+}
+
+// allowedCycle 判断 masterTable->subTable 的关联是否在allowedCycles中允许循环
+func allowedCycle(config common.DBConfig, masterTable, subTable string) bool {
+	return utils.ContainsValue(config.AllowedCycles, masterTable+"->"+subTable)
+}
+
+/**
+ * 深度优先遍历关联关系图，返回遍历中遇到的循环关联关系（回边形成的循环），不保证找出所有的简单循环，
+ * 但图中存在循环时至少返回一个，足以报告错误；每个循环返回完整的路径，首尾是同一个表，如 a -> b -> c -> a，
+ * 同一个循环只返回一次，路径从循环中名称最小的表开始
+ */
+func (graph relationGraph) cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	stack := []string{}
+	found := map[string]bool{}
+	cycles := [][]string{}
+
+	var visit func(table string)
+	visit = func(table string) {
+		state[table] = visiting
+		stack = append(stack, table)
+		for _, next := range graph[table] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				// 从栈中next的位置到当前表就是一个循环
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == next {
+						cycle := normalizeCycle(stack[i:])
+						if key := strings.Join(cycle, "->"); !found[key] {
+							found[key] = true
+							cycles = append(cycles, cycle)
+						}
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[table] = visited
+	}
+	for _, table := range utils.SortedKeys(graph) {
+		if state[table] == unvisited {
+			visit(table)
+		}
+	}
+	return cycles
+}
+
+// normalizeCycle 将循环旋转为从名称最小的表开始，并在末尾加上起始表，如 [b c a] 返回 [a b c a]
+func normalizeCycle(tables []string) []string {
+	start := 0
+	for i, table := range tables {
+		if table < tables[start] {
+			start = i
+		}
+	}
+	cycle := append([]string{}, tables[start:]...)
+	cycle = append(cycle, tables[:start]...)
+	return append(cycle, cycle[0])
+}
@@ -0,0 +1,154 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/essrt/gentoolplus/common"
+)
+
+// fk 返回 table.column 引用 referencedTable.id 的外键
+func fk(table, column, referencedTable string) common.Results {
+	return common.Results{TABLE_NAME: table, COLUMN_NAME: column, REFERENCED_TABLE_NAME: referencedTable, REFERENCED_COLUMN_NAME: "id"}
+}
+
+func TestBuildRelationGraph(t *testing.T) {
+	// staff、profile互相引用，department.manager_id引用staff，staff.department_id引用department
+	relations := []common.Results{
+		fk("staff", "profile_id", "profile"),
+		fk("profile", "staff_id", "staff"),
+		fk("department", "manager_id", "staff"),
+		fk("staff", "department_id", "department"),
+	}
+	tests := []struct {
+		name       string
+		config     common.DBConfig
+		relations  []common.Results
+		wantGraph  relationGraph
+		wantCycles [][]string
+	}{
+		{
+			name:       "互相引用的hasmany关联不形成循环",
+			relations:  relations[2:],
+			wantGraph:  relationGraph{},
+			wantCycles: [][]string{},
+		},
+		{
+			name: "互相引用的hasone关联形成循环",
+			config: common.DBConfig{
+				HasoneTables: map[string][]string{"staff": {"profile"}, "profile": {"staff"}},
+			},
+			relations:  relations[:2],
+			wantGraph:  relationGraph{"profile": {"staff"}, "staff": {"profile"}},
+			wantCycles: [][]string{{"profile", "staff", "profile"}},
+		},
+		{
+			name: "allowedCycles中允许的关联使用指针不形成循环",
+			config: common.DBConfig{
+				HasoneTables:  map[string][]string{"staff": {"profile"}, "profile": {"staff"}},
+				AllowedCycles: []string{"staff->profile"},
+			},
+			relations:  relations[:2],
+			wantGraph:  relationGraph{"profile": {"staff"}},
+			wantCycles: [][]string{},
+		},
+		{
+			name: "hasone和hasmany组成的关联不形成循环",
+			config: common.DBConfig{
+				HasoneTables: map[string][]string{"staff": {"profile"}},
+			},
+			relations:  relations[:2],
+			wantGraph:  relationGraph{"staff": {"profile"}},
+			wantCycles: [][]string{},
+		},
+		{
+			name: "belongsto关联形成循环",
+			config: common.DBConfig{
+				BelongstoTables: map[string][]string{"staff": {"department"}, "department": {"staff"}},
+			},
+			relations:  relations[2:],
+			wantGraph:  relationGraph{"department": {"staff"}, "staff": {"department"}},
+			wantCycles: [][]string{{"department", "staff", "department"}},
+		},
+		{
+			name: "bidirectionalRelations中hasone一侧的belongsto使用指针不形成循环",
+			config: common.DBConfig{
+				BidirectionalRelations: []common.BidirectionalRelation{{Table: "profile", ReferencedTable: "staff", Inverse: "hasone"}},
+			},
+			relations:  relations[1:2],
+			wantGraph:  relationGraph{"staff": {"profile"}},
+			wantCycles: [][]string{},
+		},
+		{
+			name: "自关联和many2many关联不形成循环",
+			config: common.DBConfig{
+				Many2manyTables: map[string][]string{"staff_role": {"staff", "role"}},
+			},
+			relations:  []common.Results{fk("staff", "parent_id", "staff")},
+			wantGraph:  relationGraph{},
+			wantCycles: [][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := buildRelationGraph(BuildMasterTableMap(tt.config, tt.relations))
+			if !reflect.DeepEqual(graph, tt.wantGraph) {
+				t.Errorf("buildRelationGraph() = %v, want %v", graph, tt.wantGraph)
+			}
+			if got := graph.cycles(); !reflect.DeepEqual(got, tt.wantCycles) {
+				t.Errorf("cycles() = %v, want %v", got, tt.wantCycles)
+			}
+		})
+	}
+}
+
+func TestAllowedCyclePointer(t *testing.T) {
+	config := common.DBConfig{
+		HasoneTables:  map[string][]string{"staff": {"profile"}, "profile": {"staff"}},
+		AllowedCycles: []string{"staff->profile"},
+	}
+	masterTableMap := BuildMasterTableMap(config, []common.Results{fk("staff", "profile_id", "profile"), fk("profile", "staff_id", "staff")})
+	for _, masterTable := range []string{"staff", "profile"} {
+		for _, subTable := range masterTableMap[masterTable] {
+			if want := masterTable == "staff"; subTable.POINTER != want {
+				t.Errorf("%s -> %s POINTER = %v, want %v", masterTable, subTable.TABLE_NAME, subTable.POINTER, want)
+			}
+		}
+	}
+}
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph relationGraph
+		want  [][]string
+	}{
+		{
+			name:  "没有循环",
+			graph: relationGraph{"a": {"b", "c"}, "b": {"c"}},
+			want:  [][]string{},
+		},
+		{
+			name:  "路径从名称最小的表开始",
+			graph: relationGraph{"c": {"a"}, "a": {"b"}, "b": {"c"}},
+			want:  [][]string{{"a", "b", "c", "a"}},
+		},
+		{
+			name:  "多个不相交的循环",
+			graph: relationGraph{"a": {"b"}, "b": {"a"}, "c": {"d"}, "d": {"c"}},
+			want:  [][]string{{"a", "b", "a"}, {"c", "d", "c"}},
+		},
+		{
+			name:  "指向循环的关联不属于循环",
+			graph: relationGraph{"a": {"b"}, "b": {"c"}, "c": {"b"}},
+			want:  [][]string{{"b", "c", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.graph.cycles(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package process
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/jinzhu/inflection"
+	"gorm.io/gorm"
+)
+
+// DefaultInferRelationPattern 推断关联关系时默认的列名规则，第1个分组为关联表名，如 department_id 关联 department.id
+const DefaultInferRelationPattern = `^(.+)_id$`
+
+// InferRelations 根据列名推断表之间的关联关系：列名匹配inferRelationPattern时，第1个分组作为关联表名
+// （singularTable为false时同时尝试复数表名），关联表中存在id列时认为该列引用关联表的id列。
+// relationList中已经存在的外键不会重复推断，返回推断出的关联关系
+func InferRelations(db *gorm.DB, config common.DBConfig, relationList []common.Results) ([]common.Results, error) {
+	pattern := config.InferRelationPattern
+	if pattern == "" {
+		pattern = DefaultInferRelationPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("inferRelationPattern配置错误: %w", err)
+	}
+	if re.NumSubexp() < 1 {
+		return nil, fmt.Errorf("inferRelationPattern配置错误: %s 中没有表示关联表名的分组", pattern)
+	}
+
+	tables := config.Tables
+	if len(tables) == 0 {
+		if tables, err = db.Migrator().GetTables(); err != nil {
+			return nil, fmt.Errorf("查询数据库表名失败: %w", err)
+		}
+	}
+	// 每个表的列名
+	columns := make(map[string][]string, len(tables))
+	for _, table := range tables {
+		columnTypes, err := db.Migrator().ColumnTypes(table)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 的列信息失败: %w", table, err)
+		}
+		for _, columnType := range columnTypes {
+			columns[table] = append(columns[table], columnType.Name())
+		}
+	}
+
+	inferred := []common.Results{}
+	for _, table := range tables {
+		for _, column := range columns[table] {
+			m := re.FindStringSubmatch(column)
+			if m == nil || hasRelation(relationList, table, column) {
+				continue
+			}
+			referencedTable := inferTable(m[1], config.SingularTable, columns)
+			// 自关联由parent_id等列表示，不根据列名推断
+			if referencedTable == "" || referencedTable == table {
+				continue
+			}
+			inferred = append(inferred, common.Results{
+				TABLE_NAME:             table,
+				COLUMN_NAME:            column,
+				REFERENCED_TABLE_NAME:  referencedTable,
+				REFERENCED_COLUMN_NAME: "id",
+			})
+		}
+	}
+	return inferred, nil
+}
+
+// inferTable 返回名称对应的表名，表中没有id列或者表不存在时返回空字符串
+func inferTable(name string, singularTable bool, columns map[string][]string) string {
+	candidates := []string{name}
+	if !singularTable {
+		candidates = []string{inflection.Plural(name), name}
+	}
+	for _, table := range candidates {
+		if slices.Contains(columns[table], "id") {
+			return table
+		}
+	}
+	return ""
+}
+
+// hasRelation 判断关联关系中是否已经存在表table的列column上的外键
+func hasRelation(relationList []common.Results, table, column string) bool {
+	for _, relation := range relationList {
+		if relation.TABLE_NAME == table && relation.COLUMN_NAME == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/utils"
+	"gorm.io/gen/field"
+)
+
+// joinTableFile 注册连接表模型的代码文件名，与gen生成的模型文件在同一个目录中
+const joinTableFile = "join_tables.gen.go"
+
+/**
+ * 为joinTableModels中的中间表生成SetupJoinTables函数，many2many关联使用连接表模型读写中间表，
+ * tables为当前包中生成的表，modelName返回表对应的模型名称，没有配置joinTableModels时不生成文件
+ */
+func writeJoinTables(config common.DBConfig, masterTableMap map[string][]common.SubTable, tables []string, modelName func(table string) string) error {
+	calls := []string{}
+	for _, table := range utils.SortedKeys(masterTableMap) {
+		if !utils.ContainsValue(tables, table) {
+			continue
+		}
+		for _, subTable := range masterTableMap[table] {
+			if subTable.RELATION_TYPE != field.Many2Many || !utils.ContainsValue(config.JoinTableModels, subTable.MIDDLE_TABLE) {
+				continue
+			}
+			calls = append(calls, fmt.Sprintf("if err := db.SetupJoinTable(&%s{}, %q, &%s{}); err != nil {\nreturn err\n}",
+				modelName(table), relationFieldName(subTable), modelName(subTable.MIDDLE_TABLE)))
+		}
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+
+	_, modelDir, err := utils.OutputDirs(config.OutPath, config.ModelPkgPath)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gentoolplus. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", filepath.Base(modelDir))
+	b.WriteString("import \"gorm.io/gorm\"\n\n")
+	b.WriteString("// SetupJoinTables 注册many2many关联使用的连接表模型，需要在AutoMigrate和查询之前调用，\n")
+	b.WriteString("// 之后关联查询和写入关联关系时会读写连接表模型中的全部字段\n")
+	b.WriteString("func SetupJoinTables(db *gorm.DB) error {\n")
+	for _, call := range calls {
+		b.WriteString(call + "\n")
+	}
+	b.WriteString("return nil\n}\n")
+
+	content, err := format.Source(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("格式化连接表代码失败: %w", err)
+	}
+	return os.WriteFile(filepath.Join(modelDir, joinTableFile), content, 0644)
+}
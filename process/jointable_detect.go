@@ -0,0 +1,92 @@
+package process
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/utils"
+	"gorm.io/gorm"
+)
+
+// joinTableTimestamps 中间表中除两个外键以外允许存在的列
+var joinTableTimestamps = []string{"created_at", "updated_at", "deleted_at"}
+
+// DetectJoinTables 识别many2many中间表：表中只有引用两个不同表的外键列（以及可选的created_at、updated_at、deleted_at列），
+// 并且两个外键列组成表的主键。返回值的格式与many2manyTables配置项相同，key为中间表名，value为两个关联表名
+func DetectJoinTables(db *gorm.DB, config common.DBConfig, relationList []common.Results) (map[string][]string, error) {
+	tables := config.Tables
+	if len(tables) == 0 {
+		var err error
+		if tables, err = db.Migrator().GetTables(); err != nil {
+			return nil, fmt.Errorf("查询数据库表名失败: %w", err)
+		}
+	}
+
+	// 每个表中单列外键引用的表，key为列名
+	foreignKeys := map[string]map[string]string{}
+	for _, relation := range groupRelations(filterRelations(config, relationList)) {
+		if strings.Contains(relation.COLUMN_NAME, ",") || relation.TABLE_NAME == relation.REFERENCED_TABLE_NAME {
+			continue
+		}
+		if foreignKeys[relation.TABLE_NAME] == nil {
+			foreignKeys[relation.TABLE_NAME] = map[string]string{}
+		}
+		foreignKeys[relation.TABLE_NAME][relation.COLUMN_NAME] = relation.REFERENCED_TABLE_NAME
+	}
+
+	detected := map[string][]string{}
+	for _, table := range tables {
+		if len(foreignKeys[table]) != 2 {
+			continue
+		}
+		columnTypes, err := db.Migrator().ColumnTypes(table)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 的列信息失败: %w", table, err)
+		}
+		if referencedTables := joinTableParents(columnTypes, foreignKeys[table]); referencedTables != nil {
+			detected[table] = referencedTables
+		}
+	}
+	return detected, nil
+}
+
+// joinTableParents 判断表是否为中间表，是中间表时按列的顺序返回两个外键引用的表名，否则返回nil。
+// 部分数据库驱动（如SQLite）读取不到组合主键，表中没有任何主键列时只检查列
+func joinTableParents(columnTypes []gorm.ColumnType, foreignKeys map[string]string) []string {
+	referencedTables := []string{}
+	hasPrimaryKey, foreignKeysArePrimary := false, true
+	for _, columnType := range columnTypes {
+		name := columnType.Name()
+		primaryKey, _ := columnType.PrimaryKey()
+		hasPrimaryKey = hasPrimaryKey || primaryKey
+		if referencedTable, ok := foreignKeys[name]; ok {
+			foreignKeysArePrimary = foreignKeysArePrimary && primaryKey
+			referencedTables = append(referencedTables, referencedTable)
+		} else if primaryKey || !slices.Contains(joinTableTimestamps, strings.ToLower(name)) {
+			return nil
+		}
+	}
+	if hasPrimaryKey && !foreignKeysArePrimary || len(referencedTables) != 2 || referencedTables[0] == referencedTables[1] {
+		return nil
+	}
+	return referencedTables
+}
+
+// MergeJoinTables 将识别出的中间表合并到many2manyTables中，返回合并后的配置。
+// many2manyTables中已经配置的中间表使用配置文件中的关联表，ignoreJoinTables中的表不会合并
+func MergeJoinTables(config common.DBConfig, detected map[string][]string) common.DBConfig {
+	merged := make(map[string][]string, len(config.Many2manyTables)+len(detected))
+	for key, value := range config.Many2manyTables {
+		merged[key] = value
+	}
+	for _, table := range utils.SortedKeys(detected) {
+		if _, ok := merged[table]; ok || utils.ContainsValue(config.IgnoreJoinTables, table) {
+			continue
+		}
+		merged[table] = detected[table]
+	}
+	config.Many2manyTables = merged
+	return config
+}
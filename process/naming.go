@@ -0,0 +1,73 @@
+package process
+
+import (
+	"strings"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/jinzhu/inflection"
+	"gorm.io/gen/field"
+)
+
+// RelationNamingColumn 关联字段名称根据外键列名生成，hasmany、many2many关联字段使用复数形式
+const RelationNamingColumn = "column"
+
+// defaultInflector 默认的复数形式转换，使用 github.com/jinzhu/inflection 的英文规则
+type defaultInflector struct{}
+
+func (defaultInflector) Plural(word string) string {
+	return inflection.Plural(word)
+}
+
+// nameRelations 设置关联字段名称：relationNaming为column时根据外键列名生成名称，然后使用relationNames中的配置覆盖，
+// 已经设置了名称的关联字段（如自关联的Parent、Children）不会根据外键列名重新生成
+func nameRelations(config common.DBConfig, masterTableMap map[string][]common.SubTable) {
+	inflector := config.Inflector
+	if inflector == nil {
+		inflector = defaultInflector{}
+	}
+	// 读取配置文件时map的key会被转换为小写，关联字段名称不区分大小写匹配
+	names := make(map[string]string, len(config.RelationNames))
+	for key, name := range config.RelationNames {
+		names[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(name)
+	}
+	for masterTable, subTables := range masterTableMap {
+		for i := range subTables {
+			subTable := &subTables[i]
+			if subTable.FIELD_NAME == "" && config.RelationNaming == RelationNamingColumn {
+				subTable.FIELD_NAME = columnRelationName(*subTable, inflector)
+			}
+			if name := names[strings.ToLower(masterTable+"."+relationFieldName(*subTable))]; name != "" {
+				subTable.FIELD_NAME = name
+			}
+		}
+	}
+}
+
+// columnRelationName 根据外键列名生成关联字段名称：belongsto关联使用去掉ID后缀的外键字段名，如 buyer_id 生成 Buyer；
+// hasone、hasmany关联在外键字段名与主表名不同时加上外键字段名作为前缀，如 user 表通过 order.buyer_id 关联时生成 BuyerOrders；
+// hasmany、many2many关联使用复数形式。组合外键使用表名
+func columnRelationName(subTable common.SubTable, inflector common.Inflector) string {
+	role := ""
+	if !strings.Contains(subTable.FOREIGN_KEY, ",") {
+		role = strings.TrimSuffix(subTable.FOREIGN_KEY, "ID")
+	}
+
+	switch subTable.RELATION_TYPE {
+	case field.Many2Many:
+		return inflector.Plural(subTable.TABLE_NAME_UP)
+	case field.BelongsTo:
+		if role != "" {
+			return role
+		}
+		return subTable.TABLE_NAME_UP
+	}
+
+	name := subTable.TABLE_NAME_UP
+	if role != "" && role != subTable.REFERENCED_TABLE_NAME_UP {
+		name = role + name
+	}
+	if subTable.RELATION_TYPE == field.HasMany {
+		name = inflector.Plural(name)
+	}
+	return name
+}
@@ -0,0 +1,689 @@
+package process
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/utils"
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
+)
+
+// QueryRelations 获取当前数据库中所有表之间的外键关联关系
+func QueryRelations(db *gorm.DB, config common.DBConfig) ([]common.Results, error) {
+	relationList := []common.Results{}
+	var err error
+	// 执行这条sql语句，获取当前数据库中所有表之间的外键关联关系
+	// 执行结果保存到relationList中
+	if config.DbDriver == "mysql" && len(config.Schemas) > 0 {
+		// 配置了多个schema时表名使用 schema名.表名 的形式，被引用的表可以在其他schema中
+		err = db.Raw("SELECT CONCAT(k.TABLE_SCHEMA, '.', k.TABLE_NAME) AS TABLE_NAME, k.COLUMN_NAME AS COLUMN_NAME, k.CONSTRAINT_NAME AS CONSTRAINT_NAME, CONCAT(k.REFERENCED_TABLE_SCHEMA, '.', k.REFERENCED_TABLE_NAME) AS REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME AS REFERENCED_COLUMN_NAME, rc.UPDATE_RULE AS UPDATE_RULE, rc.DELETE_RULE AS DELETE_RULE FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE k JOIN INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc ON rc.CONSTRAINT_SCHEMA = k.TABLE_SCHEMA AND rc.TABLE_NAME = k.TABLE_NAME AND rc.CONSTRAINT_NAME = k.CONSTRAINT_NAME WHERE k.TABLE_SCHEMA IN ? AND k.REFERENCED_TABLE_SCHEMA IS NOT NULL ORDER BY k.TABLE_SCHEMA, k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION;", config.Schemas).Scan(&relationList).Error
+	} else if config.DbDriver == "mysql" {
+		err = db.Raw("SELECT k.TABLE_NAME AS TABLE_NAME, k.COLUMN_NAME AS COLUMN_NAME, k.CONSTRAINT_NAME AS CONSTRAINT_NAME, k.REFERENCED_TABLE_NAME AS REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME AS REFERENCED_COLUMN_NAME, rc.UPDATE_RULE AS UPDATE_RULE, rc.DELETE_RULE AS DELETE_RULE FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE k JOIN INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc ON rc.CONSTRAINT_SCHEMA = k.TABLE_SCHEMA AND rc.TABLE_NAME = k.TABLE_NAME AND rc.CONSTRAINT_NAME = k.CONSTRAINT_NAME WHERE k.TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_SCHEMA IS NOT NULL ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION;", config.DbName).Scan(&relationList).Error
+	} else if config.DbDriver == "postgres" {
+		// 组合外键的conkey和confkey按位置一一对应，使用unnest ... WITH ORDINALITY展开，避免两个数组交叉组合
+		// 表名由schema名和表名拼接，不使用regclass，regclass在schema不在search_path中时才会带上schema名
+		err = db.Raw("SELECT c.conname AS constraint_name, n.nspname || '.' || cl.relname AS table_name, a.attname AS column_name, rn.nspname || '.' || rcl.relname AS referenced_table_name, af.attname AS referenced_column_name, CASE c.confupdtype WHEN 'c' THEN 'CASCADE' WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' WHEN 'r' THEN 'RESTRICT' ELSE 'NO ACTION' END AS update_rule, CASE c.confdeltype WHEN 'c' THEN 'CASCADE' WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' WHEN 'r' THEN 'RESTRICT' ELSE 'NO ACTION' END AS delete_rule FROM pg_constraint c JOIN pg_class cl ON cl.oid = c.conrelid JOIN pg_namespace n ON n.oid = cl.relnamespace JOIN pg_class rcl ON rcl.oid = c.confrelid JOIN pg_namespace rn ON rn.oid = rcl.relnamespace CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord) JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum JOIN pg_attribute af ON af.attrelid = c.confrelid AND af.attnum = k.refattnum WHERE c.contype = 'f' AND n.nspname IN ? ORDER BY table_name, c.conname, k.ord;", postgresSchemas(config)).Scan(&relationList).Error
+		if len(config.Schemas) == 0 {
+			// 没有配置schemas时，nspname中的表名不带schema名，与数据库中的表名保持一致
+			for i := range relationList {
+				relationList[i].TABLE_NAME = strings.TrimPrefix(relationList[i].TABLE_NAME, config.Nspname+".")
+				relationList[i].REFERENCED_TABLE_NAME = strings.TrimPrefix(relationList[i].REFERENCED_TABLE_NAME, config.Nspname+".")
+			}
+		}
+	} else if config.DbDriver == "sqlite" || config.DbDriver == "ddl" {
+		relationList, err = ProcessSqliteRelation(db)
+	} else if config.DbDriver == "sqlserver" && len(config.Schemas) > 0 {
+		// 配置了schemas时表名使用 schema名.表名 的形式，被引用的表可以在其他schema中
+		err = db.Raw("USE "+config.DbName+"; SELECT SCHEMA_NAME(t.schema_id) + '.' + t.name AS TABLE_NAME, col.name AS COLUMN_NAME, fk.name AS CONSTRAINT_NAME, SCHEMA_NAME(ref.schema_id) + '.' + ref.name AS REFERENCED_TABLE_NAME, refCol.name AS REFERENCED_COLUMN_NAME, fk.update_referential_action_desc AS UPDATE_RULE, fk.delete_referential_action_desc AS DELETE_RULE FROM sys.tables AS t INNER JOIN sys.foreign_keys AS fk ON t.object_id = fk.parent_object_id INNER JOIN sys.foreign_key_columns AS fkc ON fk.object_id = fkc.constraint_object_id INNER JOIN sys.columns AS col ON fkc.parent_column_id = col.column_id AND fkc.parent_object_id = col.object_id INNER JOIN sys.tables AS ref ON fk.referenced_object_id = ref.object_id INNER JOIN sys.columns AS refCol ON fkc.referenced_column_id = refCol.column_id AND fkc.referenced_object_id = refCol.object_id WHERE SCHEMA_NAME(t.schema_id) IN ? ORDER BY TABLE_NAME, fk.name, fkc.constraint_column_id;", config.Schemas).Scan(&relationList).Error
+	} else if config.DbDriver == "sqlserver" {
+		err = db.Raw("USE " + config.DbName + "; SELECT t.name AS TABLE_NAME, col.name AS COLUMN_NAME, fk.name AS CONSTRAINT_NAME, ref.name AS REFERENCED_TABLE_NAME, refCol.name AS REFERENCED_COLUMN_NAME, fk.update_referential_action_desc AS UPDATE_RULE, fk.delete_referential_action_desc AS DELETE_RULE FROM sys.tables AS t INNER JOIN sys.foreign_keys AS fk ON t.object_id = fk.parent_object_id INNER JOIN sys.foreign_key_columns AS fkc ON fk.object_id = fkc.constraint_object_id INNER JOIN sys.columns AS col ON fkc.parent_column_id = col.column_id AND fkc.parent_object_id = col.object_id INNER JOIN sys.tables AS ref ON fk.referenced_object_id = ref.object_id INNER JOIN sys.columns AS refCol ON fkc.referenced_column_id = refCol.column_id AND fkc.referenced_object_id = refCol.object_id ORDER BY t.name, fk.name, fkc.constraint_column_id;").Scan(&relationList).Error
+	} else {
+		return nil, fmt.Errorf("不支持的数据库类型: %s", config.DbDriver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询表关联关系失败: %w", err)
+	}
+	// sqlserver中的规则名称以下划线分隔，如 SET_NULL，统一为 SET NULL
+	for i := range relationList {
+		relationList[i].UPDATE_RULE = strings.ReplaceAll(relationList[i].UPDATE_RULE, "_", " ")
+		relationList[i].DELETE_RULE = strings.ReplaceAll(relationList[i].DELETE_RULE, "_", " ")
+	}
+	return relationList, nil
+}
+
+// postgresSchemas 返回需要查询外键的postgres schema，没有配置schemas时使用nspname
+func postgresSchemas(config common.DBConfig) []string {
+	if len(config.Schemas) > 0 {
+		return config.Schemas
+	}
+	return []string{config.Nspname}
+}
+
+// 处理sqlite数据库中的表关联关系
+func ProcessSqliteRelation(db *gorm.DB) (relationList []common.Results, err error) {
+	// 使用已打开的 SQLite 数据库连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// 查询所有表
+	rows, err := sqlDB.Query("SELECT name FROM sqlite_master WHERE type='table';")
+	if err != nil {
+		return nil, err
+	}
+
+	tableNames := []string{}
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tableNames = append(tableNames, tableName)
+	}
+	// 先关闭表名的查询结果再查询外键，内存数据库只有一个连接，嵌套查询会一直等待连接
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 遍历每个表
+	for _, tableName := range tableNames {
+		// 查询表的外键关系，表名作为参数传入，表名是关键字（如order）时同样可以查询
+		fkRows, err := sqlDB.Query("SELECT * FROM pragma_foreign_key_list(?);", tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		// 遍历外键关系
+		fks := []sqliteForeignKey{}
+		for fkRows.Next() {
+			var fk sqliteForeignKey
+			var match sql.NullString
+			if err := fkRows.Scan(&fk.id, &fk.seq, &fk.table, &fk.from, &fk.to, &fk.onUpdate, &fk.onDelete, &match); err != nil {
+				fkRows.Close()
+				return nil, err
+			}
+			fks = append(fks, fk)
+		}
+		// 先关闭外键的查询结果再查询主键，原因与查询表名时一致
+		fkRows.Close()
+		if err := fkRows.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, fk := range fks {
+			to := fk.to.String
+			// REFERENCES company 没有指定列时引用的是关联表的主键，to为NULL
+			if !fk.to.Valid {
+				primaryKeys, err := sqlitePrimaryKeys(sqlDB, fk.table)
+				if err != nil {
+					return nil, err
+				}
+				if fk.seq >= len(primaryKeys) {
+					return nil, fmt.Errorf("表 %s 的外键 %s 引用的表 %s 中没有对应的主键列", tableName, fk.from, fk.table)
+				}
+				to = primaryKeys[fk.seq]
+			}
+			// sqlite的外键没有名称，同一个外键的多个列id相同
+			relationList = append(relationList, common.Results{TABLE_NAME: tableName, COLUMN_NAME: fk.from, CONSTRAINT_NAME: fmt.Sprintf("fk_%s_%d", tableName, fk.id), REFERENCED_TABLE_NAME: fk.table, REFERENCED_COLUMN_NAME: to, UPDATE_RULE: fk.onUpdate, DELETE_RULE: fk.onDelete})
+		}
+	}
+
+	return relationList, nil
+}
+
+// sqliteForeignKey pragma_foreign_key_list返回的一行外键信息，match列不使用
+type sqliteForeignKey struct {
+	id, seq                         int
+	table, from, onUpdate, onDelete string
+	to                              sql.NullString
+}
+
+// sqlitePrimaryKeys 按主键中的顺序返回表的主键列
+func sqlitePrimaryKeys(sqlDB *sql.DB, tableName string) ([]string, error) {
+	rows, err := sqlDB.Query("SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk;", tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	primaryKeys := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		primaryKeys = append(primaryKeys, name)
+	}
+	return primaryKeys, rows.Err()
+}
+
+// MergeVirtualForeignKeys 将配置文件中声明的虚拟外键合并到数据库中的外键关联关系中，数据库中已经存在的外键不会重复添加
+func MergeVirtualForeignKeys(config common.DBConfig, relationList []common.Results) []common.Results {
+	if len(config.VirtualForeignKeys) == 0 {
+		return relationList
+	}
+	merged := append([]common.Results{}, relationList...)
+	for _, fk := range config.VirtualForeignKeys {
+		relation := common.Results{
+			TABLE_NAME:             fk.Table,
+			COLUMN_NAME:            fk.Column,
+			REFERENCED_TABLE_NAME:  fk.ReferencedTable,
+			REFERENCED_COLUMN_NAME: fk.ReferencedColumn,
+		}
+		if relation.COLUMN_NAME == "" {
+			relation.COLUMN_NAME = fk.ReferencedTable + "_id"
+		}
+		if relation.REFERENCED_COLUMN_NAME == "" {
+			relation.REFERENCED_COLUMN_NAME = "id"
+		}
+		// 只比较表和列，数据库中的外键还有约束名称和更新、删除规则
+		if !slices.ContainsFunc(merged, func(r common.Results) bool {
+			return r.TABLE_NAME == relation.TABLE_NAME && r.COLUMN_NAME == relation.COLUMN_NAME &&
+				r.REFERENCED_TABLE_NAME == relation.REFERENCED_TABLE_NAME && r.REFERENCED_COLUMN_NAME == relation.REFERENCED_COLUMN_NAME
+		}) {
+			merged = append(merged, relation)
+		}
+	}
+	return merged
+}
+
+// filterRelations 去掉ignoreRelations中忽略的外键，如果配置文件中指定了要生成的表名，则只保留指定的表名之间的关联关系
+func filterRelations(config common.DBConfig, relationList []common.Results) []common.Results {
+	finalRelationList := []common.Results{}
+	for _, v := range relationList {
+		if ignoredRelation(config, v) {
+			continue
+		}
+		if len(config.Tables) == 0 || utils.ContainsValue(config.Tables, v.TABLE_NAME) && utils.ContainsValue(config.Tables, v.REFERENCED_TABLE_NAME) {
+			finalRelationList = append(finalRelationList, v)
+		}
+	}
+	return finalRelationList
+}
+
+// ignoredRelation 判断外键是否在ignoreRelations中，可以按外键约束名称或者 子表名->关联表名 忽略
+func ignoredRelation(config common.DBConfig, relation common.Results) bool {
+	return relation.CONSTRAINT_NAME != "" && utils.ContainsValue(config.IgnoreRelations, relation.CONSTRAINT_NAME) ||
+		utils.ContainsValue(config.IgnoreRelations, relation.TABLE_NAME+"->"+relation.REFERENCED_TABLE_NAME)
+}
+
+// hasOneRelations 返回hasone配置的关联关系，格式为 主表名_子表名
+func hasOneRelations(config common.DBConfig) (relations []string) {
+	for _, key := range utils.SortedKeys(config.HasoneTables) {
+		for _, value := range config.HasoneTables[key] {
+			relations = append(relations, key+"_"+value)
+		}
+	}
+	return relations
+}
+
+// hasManyRelations 返回hasmany配置的关联关系，格式为 主表名_子表名
+func hasManyRelations(config common.DBConfig) (relations []string) {
+	for _, key := range utils.SortedKeys(config.HasmanyTables) {
+		for _, value := range config.HasmanyTables[key] {
+			relations = append(relations, key+"_"+value)
+		}
+	}
+	return relations
+}
+
+// belongsToRelations 返回belongsto配置的关联关系，格式为 主表名_子表名
+func belongsToRelations(config common.DBConfig) (relations []string) {
+	for _, key := range utils.SortedKeys(config.BelongstoTables) {
+		for _, value := range config.BelongstoTables[key] {
+			relations = append(relations, value+"_"+key)
+		}
+	}
+	return relations
+}
+
+// CheckTableRelations 检查hasone、hasmany、belongsto配置的关联关系以及joinTableModels中的中间表在数据库中是否存在外键，以及表之间是否存在循环关联关系，
+// 返回收集到的全部配置错误
+func CheckTableRelations(config common.DBConfig, relationList []common.Results) common.ValidationErrors {
+	errs := common.ValidationErrors{}
+
+	tmpRelationList := []string{}
+	for _, v := range filterRelations(config, relationList) {
+		tmpRelationList = append(tmpRelationList, v.REFERENCED_TABLE_NAME+"_"+v.TABLE_NAME)
+	}
+
+	// 检查hasone、hasmany和belongsto配置的关联关系在relationList中是否存在
+	for _, key := range utils.SortedKeys(config.HasoneTables) {
+		for i, value := range config.HasoneTables[key] {
+			if !utils.ContainsValue(tmpRelationList, key+"_"+value) {
+				errs = append(errs, &common.MissingForeignKeyError{Path: common.ConfigPath("hasoneTables", key, i), Table: value, ReferencedTable: key})
+			}
+		}
+	}
+
+	for _, key := range utils.SortedKeys(config.HasmanyTables) {
+		for i, value := range config.HasmanyTables[key] {
+			if !utils.ContainsValue(tmpRelationList, key+"_"+value) {
+				errs = append(errs, &common.MissingForeignKeyError{Path: common.ConfigPath("hasmanyTables", key, i), Table: value, ReferencedTable: key})
+			}
+		}
+	}
+
+	for i, relation := range config.BidirectionalRelations {
+		found := false
+		for _, v := range filterRelations(config, relationList) {
+			if v.TABLE_NAME == relation.Table && v.REFERENCED_TABLE_NAME == relation.ReferencedTable && (relation.Column == "" || v.COLUMN_NAME == relation.Column) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, &common.MissingForeignKeyError{Path: common.ConfigPath("bidirectionalRelations", "", i), Table: relation.Table, ReferencedTable: relation.ReferencedTable})
+		}
+	}
+
+	for _, key := range utils.SortedKeys(config.BelongstoTables) {
+		for i, value := range config.BelongstoTables[key] {
+			if !utils.ContainsValue(tmpRelationList, value+"_"+key) {
+				errs = append(errs, &common.MissingForeignKeyError{Path: common.ConfigPath("belongstoTables", key, i), Table: key, ReferencedTable: value})
+			}
+		}
+	}
+
+	// joinTableModels中的中间表需要有引用两个表的外键，用于生成两个表到连接表模型的hasmany关联
+	for i, middleTable := range config.JoinTableModels {
+		tables := config.Many2manyTables[middleTable]
+		if len(tables) != 2 {
+			continue
+		}
+		first, ok := joinTableForeignKey(relationList, middleTable, tables[0], "")
+		if !ok {
+			errs = append(errs, &common.MissingForeignKeyError{Path: common.ConfigPath("joinTableModels", "", i), Table: middleTable, ReferencedTable: tables[0]})
+		}
+		if _, ok := joinTableForeignKey(relationList, middleTable, tables[1], first.COLUMN_NAME); !ok {
+			errs = append(errs, &common.MissingForeignKeyError{Path: common.ConfigPath("joinTableModels", "", i), Table: middleTable, ReferencedTable: tables[1]})
+		}
+	}
+
+	masterTableMap := BuildMasterTableMap(config, relationList)
+	for _, masterTable := range utils.SortedKeys(masterTableMap) {
+		// 同一个模型中的关联字段名称不能重复，如同一个表的多个外键引用同一个表时需要使用relationNaming或relationNames区分
+		fieldNames := []string{}
+		fieldTables := map[string]string{}
+		for _, subTable := range masterTableMap[masterTable] {
+			fieldNames = append(fieldNames, relationFieldName(subTable))
+			fieldTables[relationFieldName(subTable)] = subTable.TABLE_NAME
+		}
+		if name, exits := utils.HasDuplicate(fieldNames); exits {
+			table := fieldTables[name]
+			errs = append(errs, &common.DuplicateRelationFieldError{Path: relationPath(config, masterTable, table), Table: masterTable, Field: name})
+		}
+	}
+
+	// 检查表之间是否存在循环关联关系，错误的配置项路径为循环中第一个关联所在的配置项
+	for _, cycle := range buildRelationGraph(masterTableMap).cycles() {
+		errs = append(errs, &common.CyclicRelationError{Path: relationPath(config, cycle[0], cycle[1]), Tables: cycle})
+	}
+	return errs
+}
+
+// relationPath 返回两个表之间的关联关系所在的配置项路径，关联关系来自数据库外键时返回tables配置项路径
+func relationPath(config common.DBConfig, masterTable, subTable string) string {
+	for i, value := range config.HasoneTables[masterTable] {
+		if value == subTable {
+			return common.ConfigPath("hasoneTables", masterTable, i)
+		}
+	}
+	for i, value := range config.BelongstoTables[masterTable] {
+		if value == subTable {
+			return common.ConfigPath("belongstoTables", masterTable, i)
+		}
+	}
+	for i, value := range config.HasmanyTables[masterTable] {
+		if value == subTable {
+			return common.ConfigPath("hasmanyTables", masterTable, i)
+		}
+	}
+	for _, middleTable := range utils.SortedKeys(config.Many2manyTables) {
+		if v := config.Many2manyTables[middleTable]; len(v) == 2 && (v[0] == masterTable && v[1] == subTable || v[0] == subTable && v[1] == masterTable) {
+			return common.ConfigPath("many2manyTables", middleTable, -1)
+		}
+	}
+	return common.ConfigPath("tables", "", -1)
+}
+
+// groupRelations 将同一个外键约束的多个列合并为一个关联关系，组合外键的列名和关联列名按列的顺序以逗号分隔
+func groupRelations(relationList []common.Results) []common.Results {
+	grouped := []common.Results{}
+	index := map[string]int{}
+	for _, relation := range relationList {
+		key := relation.TABLE_NAME + "." + relation.CONSTRAINT_NAME
+		if i, ok := index[key]; ok && relation.CONSTRAINT_NAME != "" && grouped[i].REFERENCED_TABLE_NAME == relation.REFERENCED_TABLE_NAME {
+			grouped[i].COLUMN_NAME += "," + relation.COLUMN_NAME
+			grouped[i].REFERENCED_COLUMN_NAME += "," + relation.REFERENCED_COLUMN_NAME
+			continue
+		}
+		index[key] = len(grouped)
+		grouped = append(grouped, relation)
+	}
+	return grouped
+}
+
+// constraintTag 返回外键ON DELETE、ON UPDATE规则对应的gorm constraint标签，如 OnDelete:SET NULL,OnUpdate:CASCADE，
+// 规则为数据库默认的NO ACTION或者未知时不生成
+func constraintTag(relation common.Results) string {
+	rules := []string{}
+	if rule := strings.ToUpper(relation.DELETE_RULE); rule != "" && rule != "NO ACTION" {
+		rules = append(rules, "OnDelete:"+rule)
+	}
+	if rule := strings.ToUpper(relation.UPDATE_RULE); rule != "" && rule != "NO ACTION" {
+		rules = append(rules, "OnUpdate:"+rule)
+	}
+	return strings.Join(rules, ",")
+}
+
+// selfReferenceSubTables 将自关联外键（如 category.parent_id 引用 category.id）转换为两个关联字段：
+// 引用上级记录的belongsto字段（默认为 Parent *Category）和下级记录的hasmany字段（默认为 Children []Category），
+// multiple为true表示表中有多个自关联外键，此时默认名称根据外键列名生成，如 manager_id 生成 Manager 和 ManagerChildren
+func selfReferenceSubTables(config common.DBConfig, st common.SubTable, multiple bool) []common.SubTable {
+	names := common.SelfReference{Parent: "Parent", Children: "Children"}
+	if multiple {
+		names.Parent = strings.ReplaceAll(strings.TrimSuffix(st.COLUMN_NAME_UP, "ID"), ",", "")
+		names.Children = names.Parent + "Children"
+	}
+	for _, key := range []string{st.TABLE_NAME, st.TABLE_NAME + "." + st.COLUMN_NAME} {
+		if configured, ok := config.SelfReferences[key]; ok {
+			if configured.Parent != "" {
+				names.Parent = configured.Parent
+			}
+			if configured.Children != "" {
+				names.Children = configured.Children
+			}
+		}
+	}
+
+	parent := st
+	parent.RELATION_TYPE = field.BelongsTo
+	parent.FIELD_NAME = names.Parent
+	// 结构体不能以值类型包含自身，belongsto字段使用指针
+	parent.POINTER = true
+	// 两个字段对应同一个外键约束，只在hasmany字段上生成constraint标签，避免AutoMigrate重复创建外键
+	parent.CONSTRAINT = ""
+
+	children := st
+	children.FIELD_NAME = names.Children
+	return []common.SubTable{parent, children}
+}
+
+// appendJoinTable 添加表到连接表模型的hasmany关联，外键为中间表中引用该表的外键，
+// 中间表通过外键约束已经生成了hasmany关联或者中间表中没有引用该表的外键时不添加
+func appendJoinTable(subTables []common.SubTable, relationList []common.Results, table, middleTable string) []common.SubTable {
+	for _, subTable := range subTables {
+		if subTable.TABLE_NAME == middleTable && subTable.RELATION_TYPE == field.HasMany {
+			return subTables
+		}
+	}
+	fk, ok := joinTableForeignKey(relationList, middleTable, table, "")
+	if !ok {
+		return subTables
+	}
+	return append(subTables, common.SubTable{
+		TABLE_NAME:               middleTable,
+		COLUMN_NAME:              fk.COLUMN_NAME,
+		TABLE_NAME_UP:            utils.Case2Camel(middleTable),
+		COLUMN_NAME_UP:           utils.FieldNames(fk.COLUMN_NAME),
+		REFERENCED_TABLE_NAME:    table,
+		REFERENCED_TABLE_NAME_UP: utils.Case2Camel(table),
+		RELATION_TYPE:            field.HasMany,
+		FOREIGN_KEY:              utils.FieldNames(fk.COLUMN_NAME),
+		REFERENCES:               utils.FieldNames(fk.REFERENCED_COLUMN_NAME),
+		CONSTRAINT:               constraintTag(fk),
+	})
+}
+
+// joinTableForeignKey 返回中间表中引用表table的外键（包括虚拟外键），组合外键的列以逗号分隔，
+// exclude为已经使用的外键列，自关联的many2many中间表有两个引用同一个表的外键时用于取第二个外键
+func joinTableForeignKey(relationList []common.Results, middleTable, table, exclude string) (common.Results, bool) {
+	for _, relation := range groupRelations(relationList) {
+		if relation.TABLE_NAME == middleTable && relation.REFERENCED_TABLE_NAME == table && relation.COLUMN_NAME != exclude {
+			return relation, true
+		}
+	}
+	return common.Results{}, false
+}
+
+// setJoinKeys 根据中间表的外键设置many2many关联的foreignKey、references、joinForeignKey、joinReferences，
+// 与gorm的默认值（引用的列为id，中间表中的列为 模型名ID）一致时不设置，中间表中没有外键时使用gorm的默认值
+func setJoinKeys(st *common.SubTable, relationList []common.Results) {
+	owner, ok := joinTableForeignKey(relationList, st.MIDDLE_TABLE, st.REFERENCED_TABLE_NAME, "")
+	if !ok {
+		return
+	}
+	target, ok := joinTableForeignKey(relationList, st.MIDDLE_TABLE, st.TABLE_NAME, owner.COLUMN_NAME)
+	if !ok {
+		return
+	}
+	if owner.REFERENCED_COLUMN_NAME != "id" {
+		st.FOREIGN_KEY = utils.FieldNames(owner.REFERENCED_COLUMN_NAME)
+	}
+	if target.REFERENCED_COLUMN_NAME != "id" {
+		st.REFERENCES = utils.FieldNames(target.REFERENCED_COLUMN_NAME)
+	}
+	if joinForeignKey := utils.FieldNames(owner.COLUMN_NAME); joinForeignKey != st.REFERENCED_TABLE_NAME_UP+utils.FieldNames(owner.REFERENCED_COLUMN_NAME) {
+		st.JOIN_FOREIGN_KEY = joinForeignKey
+	}
+	if joinReferences := utils.FieldNames(target.COLUMN_NAME); joinReferences != st.TABLE_NAME_UP+utils.FieldNames(target.REFERENCED_COLUMN_NAME) {
+		st.JOIN_REFERENCES = joinReferences
+	}
+}
+
+// belongsToSubTable 返回子表到主表的belongsto关联，st为主表到子表的关联
+func belongsToSubTable(sub common.Results, st common.SubTable) common.SubTable {
+	st1 := common.SubTable{
+		TABLE_NAME:               sub.REFERENCED_TABLE_NAME,                    //子表名
+		COLUMN_NAME:              sub.REFERENCED_COLUMN_NAME,                   //子表列名
+		TABLE_NAME_UP:            utils.Case2Camel(sub.REFERENCED_TABLE_NAME),  //将子表名下划线去掉，转换成首字母大写
+		COLUMN_NAME_UP:           utils.FieldNames(sub.REFERENCED_COLUMN_NAME), //将子表列名中以id结尾的字段中的id转换成ID格式，再将子表列名下划线去掉，转换成首字母大写
+		REFERENCED_TABLE_NAME:    sub.TABLE_NAME,                               //关联表名
+		REFERENCED_TABLE_NAME_UP: utils.Case2Camel(sub.TABLE_NAME),             //将关联表名下划线去掉，转换成首字母大写
+		RELATION_TYPE:            field.BelongsTo,                              //关联关系类型
+	}
+	// belongsto关系的外键字段在当前表中，references为关联表中被引用的字段
+	st1.FOREIGN_KEY = st.COLUMN_NAME_UP
+	st1.REFERENCES = st1.COLUMN_NAME_UP
+	st1.CONSTRAINT = st.CONSTRAINT
+	return st1
+}
+
+// bidirectionalRelation 判断外键是否在bidirectionalRelations中声明，返回主表一侧的关联关系类型
+func bidirectionalRelation(config common.DBConfig, sub common.Results) (field.RelationshipType, bool) {
+	for _, relation := range config.BidirectionalRelations {
+		if relation.Table != sub.TABLE_NAME || relation.ReferencedTable != sub.REFERENCED_TABLE_NAME ||
+			relation.Column != "" && relation.Column != sub.COLUMN_NAME {
+			continue
+		}
+		if strings.EqualFold(relation.Inverse, "hasone") {
+			return field.HasOne, true
+		}
+		return field.HasMany, true
+	}
+	return "", false
+}
+
+// BuildMasterTableMap 将关联关系按照主表名进行分组，map的key为主表名，value为子表切片
+func BuildMasterTableMap(config common.DBConfig, relationList []common.Results) map[string][]common.SubTable {
+	// hasOne关系列表
+	hasOneRelationList := hasOneRelations(config)
+	// belongsTo关系列表
+	belongsToRelationList := belongsToRelations(config)
+	// hasMany关系列表，配置了hasmanyTables时没有配置关联关系的外键不生成关联字段
+	hasManyRelationList := hasManyRelations(config)
+	explicit := len(config.HasmanyTables) > 0
+
+	masterTableMap := make(map[string][]common.SubTable)
+	relations := groupRelations(filterRelations(config, relationList))
+	// 每个表中自关联外键的数量
+	selfReferenceCount := map[string]int{}
+	for _, sub := range relations {
+		if sub.TABLE_NAME == sub.REFERENCED_TABLE_NAME {
+			selfReferenceCount[sub.TABLE_NAME]++
+		}
+	}
+	// 将finalRelationList中的数据按照关联表名进行分组，将关联了父表名的所有子表数据放到一个切片中，然后将切片放到map中，map的key为父表名，value为子表切片
+	for _, sub := range relations {
+		st := common.SubTable{
+			TABLE_NAME:               sub.TABLE_NAME,                              //子表名
+			COLUMN_NAME:              sub.COLUMN_NAME,                             //子表列名
+			TABLE_NAME_UP:            utils.Case2Camel(sub.TABLE_NAME),            //将子表名下划线去掉，转换成首字母大写
+			COLUMN_NAME_UP:           utils.FieldNames(sub.COLUMN_NAME),           //将子表列名中以id结尾的字段中的id转换成ID格式，再将子表列名下划线去掉，转换成首字母大写
+			REFERENCED_TABLE_NAME:    sub.REFERENCED_TABLE_NAME,                   //关联表名
+			REFERENCED_TABLE_NAME_UP: utils.Case2Camel(sub.REFERENCED_TABLE_NAME), //将关联表名下划线去掉，转换成首字母大写
+			RELATION_TYPE:            field.HasMany,                               //关联关系类型
+		}
+		// 外键字段在子表中，references为关联表中被引用的字段（可能不是主键）
+		st.FOREIGN_KEY = st.COLUMN_NAME_UP
+		st.REFERENCES = utils.FieldNames(sub.REFERENCED_COLUMN_NAME)
+		st.CONSTRAINT = constraintTag(sub)
+
+		if sub.TABLE_NAME == sub.REFERENCED_TABLE_NAME {
+			masterTableMap[sub.TABLE_NAME] = append(masterTableMap[sub.TABLE_NAME], selfReferenceSubTables(config, st, selfReferenceCount[sub.TABLE_NAME] > 1)...)
+		} else if inverse, ok := bidirectionalRelation(config, sub); ok {
+			// 同时生成子表的belongsto关联和主表的hasone或hasmany关联，两边使用相同的foreignKey和references，
+			// 外键约束只在主表的关联字段上生成，避免AutoMigrate重复创建外键
+			child := belongsToSubTable(sub, st)
+			child.CONSTRAINT = ""
+			// 两个结构体不能以值类型互相包含，hasone时belongsto字段使用指针
+			child.POINTER = inverse == field.HasOne
+			masterTableMap[sub.TABLE_NAME] = append(masterTableMap[sub.TABLE_NAME], child)
+			st.RELATION_TYPE = inverse
+			masterTableMap[sub.REFERENCED_TABLE_NAME] = append(masterTableMap[sub.REFERENCED_TABLE_NAME], st)
+		} else if utils.ContainsValue(hasOneRelationList, sub.REFERENCED_TABLE_NAME+"_"+sub.TABLE_NAME) {
+			st.RELATION_TYPE = field.HasOne
+			masterTableMap[sub.REFERENCED_TABLE_NAME] = append(masterTableMap[sub.REFERENCED_TABLE_NAME], st)
+		} else if utils.ContainsValue(belongsToRelationList, sub.REFERENCED_TABLE_NAME+"_"+sub.TABLE_NAME) {
+			masterTableMap[sub.TABLE_NAME] = append(masterTableMap[sub.TABLE_NAME], belongsToSubTable(sub, st))
+		} else if !explicit || utils.ContainsValue(hasManyRelationList, sub.REFERENCED_TABLE_NAME+"_"+sub.TABLE_NAME) {
+			masterTableMap[sub.REFERENCED_TABLE_NAME] = append(masterTableMap[sub.REFERENCED_TABLE_NAME], st)
+		}
+	}
+
+	for _, middleTable := range utils.SortedKeys(config.Many2manyTables) {
+		v := config.Many2manyTables[middleTable]
+		if len(v) != 2 {
+			continue
+		}
+
+		st2 := common.SubTable{
+			TABLE_NAME:               v[1],                   //子表名
+			TABLE_NAME_UP:            utils.Case2Camel(v[1]), //将子表名下划线去掉，转换成首字母大写
+			REFERENCED_TABLE_NAME:    v[0],                   //关联表名
+			REFERENCED_TABLE_NAME_UP: utils.Case2Camel(v[0]),
+			RELATION_TYPE:            field.Many2Many, //关联关系类型
+			MIDDLE_TABLE:             middleTable,     //中间表名
+		}
+
+		st3 := common.SubTable{
+			TABLE_NAME:               v[0],                   //子表名
+			TABLE_NAME_UP:            utils.Case2Camel(v[0]), //将子表名下划线去掉，转换成首字母大写
+			REFERENCED_TABLE_NAME:    v[1],                   //关联表名
+			REFERENCED_TABLE_NAME_UP: utils.Case2Camel(v[1]),
+			RELATION_TYPE:            field.Many2Many, //关联关系类型
+			MIDDLE_TABLE:             middleTable,     //中间表名
+		}
+
+		// 中间表的列不是gorm默认的列时，使用中间表中的外键设置连接列
+		setJoinKeys(&st2, relationList)
+		setJoinKeys(&st3, relationList)
+
+		masterTableMap[v[0]] = append(masterTableMap[v[0]], st2)
+		masterTableMap[v[1]] = append(masterTableMap[v[1]], st3)
+
+		// 生成连接表模型时，两个表同时生成到连接表模型的hasmany关联，用于读写中间表中的其他列
+		if utils.ContainsValue(config.JoinTableModels, middleTable) {
+			for _, table := range v {
+				masterTableMap[table] = appendJoinTable(masterTableMap[table], relationList, table, middleTable)
+			}
+		}
+	}
+
+	// 多态关联在每个拥有者表的模型中生成关联字段
+	for _, relation := range config.PolymorphicRelations {
+		for _, owner := range relation.Owners {
+			masterTableMap[owner] = append(masterTableMap[owner], polymorphicSubTable(relation, owner))
+		}
+	}
+
+	// allowedCycles中允许循环的hasone、belongsto关联字段使用指针，结构体不能以值类型互相包含
+	for masterTable, subTables := range masterTableMap {
+		for i, subTable := range subTables {
+			if valueRelation(subTable) && allowedCycle(config, masterTable, subTable.TABLE_NAME) {
+				subTables[i].POINTER = true
+			}
+		}
+	}
+
+	nameRelations(config, masterTableMap)
+	return masterTableMap
+}
+
+// polymorphicSubTable 返回拥有者表owner到多态子表的关联，如 post 的 Comment []Comment `gorm:"polymorphic:Commentable;polymorphicValue:post"`，
+// 子表中需要存在 前缀_type、前缀_id 两列
+func polymorphicSubTable(relation common.PolymorphicRelation, owner string) common.SubTable {
+	polymorphic := polymorphicPrefix(relation)
+	value := relation.Values[owner]
+	if value == "" {
+		value = owner
+	}
+	st := common.SubTable{
+		TABLE_NAME:               relation.Table,
+		COLUMN_NAME:              polymorphic + "_id",
+		TABLE_NAME_UP:            utils.Case2Camel(relation.Table),
+		COLUMN_NAME_UP:           utils.FieldNames(polymorphic + "_id"),
+		REFERENCED_TABLE_NAME:    owner,
+		REFERENCED_TABLE_NAME_UP: utils.Case2Camel(owner),
+		RELATION_TYPE:            field.HasMany,
+		POLYMORPHIC:              utils.Case2Camel(polymorphic),
+		POLYMORPHIC_VALUE:        value,
+	}
+	if relation.HasOne {
+		st.RELATION_TYPE = field.HasOne
+	}
+	return st
+}
+
+// polymorphicPrefix 返回多态列名前缀，没有配置polymorphic时使用 子表名able
+func polymorphicPrefix(relation common.PolymorphicRelation) string {
+	if relation.Polymorphic != "" {
+		return relation.Polymorphic
+	}
+	return relation.Table + "able"
+}
+
+// CheckPolymorphicColumns 检查polymorphicRelations配置的多态子表中是否存在 前缀_type、前缀_id 两列，
+// 配置错误全部收集后返回，读取列信息失败时返回error
+func CheckPolymorphicColumns(db *gorm.DB, config common.DBConfig) (common.ValidationErrors, error) {
+	errs := common.ValidationErrors{}
+	for i, relation := range config.PolymorphicRelations {
+		columnTypes, err := db.Migrator().ColumnTypes(relation.Table)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 的列信息失败: %w", relation.Table, err)
+		}
+		columns := make([]string, 0, len(columnTypes))
+		for _, columnType := range columnTypes {
+			columns = append(columns, columnType.Name())
+		}
+		path := common.ConfigPath("polymorphicRelations", "", i)
+		if relation.Polymorphic != "" {
+			path += ".polymorphic"
+		} else {
+			path += ".table"
+		}
+		prefix := polymorphicPrefix(relation)
+		for _, column := range []string{prefix + "_type", prefix + "_id"} {
+			if !utils.ContainsValue(columns, column) {
+				errs = append(errs, &common.MissingColumnError{Path: path, Table: relation.Table, Column: column})
+			}
+		}
+	}
+	return errs, nil
+}
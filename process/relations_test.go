@@ -0,0 +1,781 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/essrt/gentoolplus/common"
+	"github.com/essrt/gentoolplus/ddl"
+	"github.com/essrt/gentoolplus/utils"
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestProcessSqliteRelation(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []common.Results
+	}{
+		{
+			name: "普通表名",
+			sql: "CREATE TABLE company (id integer PRIMARY KEY);\n" +
+				"CREATE TABLE staff (id integer PRIMARY KEY, company_id integer REFERENCES company(id) ON DELETE CASCADE);",
+			want: []common.Results{
+				{TABLE_NAME: "staff", COLUMN_NAME: "company_id", CONSTRAINT_NAME: "fk_staff_0", REFERENCED_TABLE_NAME: "company", REFERENCED_COLUMN_NAME: "id", UPDATE_RULE: "NO ACTION", DELETE_RULE: "CASCADE"},
+			},
+		},
+		{
+			name: "省略引用列时使用关联表的主键",
+			sql: "CREATE TABLE company (code varchar(10) PRIMARY KEY);\n" +
+				"CREATE TABLE region (country char(2), no integer, PRIMARY KEY (country, no));\n" +
+				"CREATE TABLE staff (id integer PRIMARY KEY, company_code varchar(10) REFERENCES company, country char(2), region_no integer, FOREIGN KEY (country, region_no) REFERENCES region);",
+			want: []common.Results{
+				{TABLE_NAME: "staff", COLUMN_NAME: "country", CONSTRAINT_NAME: "fk_staff_0", REFERENCED_TABLE_NAME: "region", REFERENCED_COLUMN_NAME: "country", UPDATE_RULE: "NO ACTION", DELETE_RULE: "NO ACTION"},
+				{TABLE_NAME: "staff", COLUMN_NAME: "region_no", CONSTRAINT_NAME: "fk_staff_0", REFERENCED_TABLE_NAME: "region", REFERENCED_COLUMN_NAME: "no", UPDATE_RULE: "NO ACTION", DELETE_RULE: "NO ACTION"},
+				{TABLE_NAME: "staff", COLUMN_NAME: "company_code", CONSTRAINT_NAME: "fk_staff_1", REFERENCED_TABLE_NAME: "company", REFERENCED_COLUMN_NAME: "code", UPDATE_RULE: "NO ACTION", DELETE_RULE: "NO ACTION"},
+			},
+		},
+		{
+			name: "表名是关键字",
+			sql: "CREATE TABLE `order` (id integer PRIMARY KEY);\n" +
+				"CREATE TABLE `group` (id integer PRIMARY KEY);\n" +
+				"CREATE TABLE `select` (id integer PRIMARY KEY, order_id integer REFERENCES `order`(id), group_id integer REFERENCES `group`(id));",
+			want: []common.Results{
+				{TABLE_NAME: "select", COLUMN_NAME: "group_id", CONSTRAINT_NAME: "fk_select_0", REFERENCED_TABLE_NAME: "group", REFERENCED_COLUMN_NAME: "id", UPDATE_RULE: "NO ACTION", DELETE_RULE: "NO ACTION"},
+				{TABLE_NAME: "select", COLUMN_NAME: "order_id", CONSTRAINT_NAME: "fk_select_1", REFERENCED_TABLE_NAME: "order", REFERENCED_COLUMN_NAME: "id", UPDATE_RULE: "NO ACTION", DELETE_RULE: "NO ACTION"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schema.sql")
+			if err := os.WriteFile(path, []byte(tt.sql), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			db, err := ddl.Open(path, &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ProcessSqliteRelation(db)
+			if err != nil {
+				t.Fatalf("ProcessSqliteRelation() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProcessSqliteRelation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeVirtualForeignKeys(t *testing.T) {
+	dbRelation := common.Results{TABLE_NAME: "staff", COLUMN_NAME: "company_id", CONSTRAINT_NAME: "fk_staff_company", REFERENCED_TABLE_NAME: "company", REFERENCED_COLUMN_NAME: "id", UPDATE_RULE: "CASCADE", DELETE_RULE: "SET NULL"}
+	tests := []struct {
+		name string
+		fks  []common.VirtualForeignKey
+		want []common.Results
+	}{
+		{
+			name: "没有虚拟外键",
+			want: []common.Results{dbRelation},
+		},
+		{
+			name: "与数据库中的外键重复",
+			fks:  []common.VirtualForeignKey{{Table: "staff", Column: "company_id", ReferencedTable: "company", ReferencedColumn: "id"}},
+			want: []common.Results{dbRelation},
+		},
+		{
+			name: "省略列名时与数据库中的外键重复",
+			fks:  []common.VirtualForeignKey{{Table: "staff", ReferencedTable: "company"}},
+			want: []common.Results{dbRelation},
+		},
+		{
+			name: "虚拟外键之间重复",
+			fks: []common.VirtualForeignKey{
+				{Table: "staff", Column: "dept_id", ReferencedTable: "department"},
+				{Table: "staff", Column: "dept_id", ReferencedTable: "department", ReferencedColumn: "id"},
+			},
+			want: []common.Results{dbRelation, {TABLE_NAME: "staff", COLUMN_NAME: "dept_id", REFERENCED_TABLE_NAME: "department", REFERENCED_COLUMN_NAME: "id"}},
+		},
+		{
+			name: "引用列不同的虚拟外键",
+			fks:  []common.VirtualForeignKey{{Table: "staff", ReferencedTable: "company", ReferencedColumn: "code"}},
+			want: []common.Results{dbRelation, {TABLE_NAME: "staff", COLUMN_NAME: "company_id", REFERENCED_TABLE_NAME: "company", REFERENCED_COLUMN_NAME: "code"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeVirtualForeignKeys(common.DBConfig{VirtualForeignKeys: tt.fks}, []common.Results{dbRelation})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeVirtualForeignKeys() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPolymorphicColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	sql := "CREATE TABLE post (id integer PRIMARY KEY);\n" +
+		"CREATE TABLE comment (id integer PRIMARY KEY, commentable_type text, commentable_id integer);\n" +
+		"CREATE TABLE tag (id integer PRIMARY KEY, taggable_id integer);"
+	if err := os.WriteFile(path, []byte(sql), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := ddl.Open(path, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		relations []common.PolymorphicRelation
+		want      common.ValidationErrors
+	}{
+		{
+			name:      "配置的多态列存在",
+			relations: []common.PolymorphicRelation{{Table: "comment", Polymorphic: "commentable", Owners: []string{"post"}}},
+			want:      common.ValidationErrors{},
+		},
+		{
+			name:      "默认多态列存在",
+			relations: []common.PolymorphicRelation{{Table: "comment", Owners: []string{"post"}}},
+			want:      common.ValidationErrors{},
+		},
+		{
+			name:      "配置的多态列不存在",
+			relations: []common.PolymorphicRelation{{Table: "comment", Polymorphic: "owner", Owners: []string{"post"}}},
+			want: common.ValidationErrors{
+				&common.MissingColumnError{Path: "database.polymorphicRelations[0].polymorphic", Table: "comment", Column: "owner_type"},
+				&common.MissingColumnError{Path: "database.polymorphicRelations[0].polymorphic", Table: "comment", Column: "owner_id"},
+			},
+		},
+		{
+			name: "默认多态列不存在",
+			relations: []common.PolymorphicRelation{
+				{Table: "comment", Owners: []string{"post"}},
+				{Table: "tag", Owners: []string{"post"}},
+			},
+			want: common.ValidationErrors{
+				&common.MissingColumnError{Path: "database.polymorphicRelations[1].table", Table: "tag", Column: "tagable_type"},
+				&common.MissingColumnError{Path: "database.polymorphicRelations[1].table", Table: "tag", Column: "tagable_id"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckPolymorphicColumns(db, common.DBConfig{PolymorphicRelations: tt.relations})
+			if err != nil {
+				t.Fatalf("CheckPolymorphicColumns() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckPolymorphicColumns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinTableModels(t *testing.T) {
+	tests := []struct {
+		name      string
+		hasmany   map[string][]string
+		relations []common.Results
+		want      map[string][]common.SubTable
+	}{
+		{
+			name:      "中间表使用默认的列",
+			relations: []common.Results{fk("author_book", "author_id", "author"), fk("author_book", "book_id", "book")},
+			want: map[string][]common.SubTable{
+				"author": {
+					{TABLE_NAME: "author_book", TABLE_NAME_UP: "AuthorBook", COLUMN_NAME: "author_id", COLUMN_NAME_UP: "AuthorID", REFERENCED_TABLE_NAME: "author", REFERENCED_TABLE_NAME_UP: "Author", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "AuthorID", REFERENCES: "ID"},
+					{TABLE_NAME: "book", TABLE_NAME_UP: "Book", REFERENCED_TABLE_NAME: "author", REFERENCED_TABLE_NAME_UP: "Author", RELATION_TYPE: field.Many2Many, MIDDLE_TABLE: "author_book"},
+				},
+				"book": {
+					{TABLE_NAME: "author_book", TABLE_NAME_UP: "AuthorBook", COLUMN_NAME: "book_id", COLUMN_NAME_UP: "BookID", REFERENCED_TABLE_NAME: "book", REFERENCED_TABLE_NAME_UP: "Book", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "BookID", REFERENCES: "ID"},
+					{TABLE_NAME: "author", TABLE_NAME_UP: "Author", REFERENCED_TABLE_NAME: "book", REFERENCED_TABLE_NAME_UP: "Book", RELATION_TYPE: field.Many2Many, MIDDLE_TABLE: "author_book"},
+				},
+			},
+		},
+		{
+			name: "中间表的列和引用的列不是默认的列",
+			relations: []common.Results{
+				{TABLE_NAME: "author_book", COLUMN_NAME: "author_ref", CONSTRAINT_NAME: "fk_author_book_0", REFERENCED_TABLE_NAME: "author", REFERENCED_COLUMN_NAME: "id", DELETE_RULE: "CASCADE"},
+				{TABLE_NAME: "author_book", COLUMN_NAME: "book_isbn", CONSTRAINT_NAME: "fk_author_book_1", REFERENCED_TABLE_NAME: "book", REFERENCED_COLUMN_NAME: "isbn"},
+			},
+			want: map[string][]common.SubTable{
+				"author": {
+					{TABLE_NAME: "author_book", TABLE_NAME_UP: "AuthorBook", COLUMN_NAME: "author_ref", COLUMN_NAME_UP: "AuthorRef", REFERENCED_TABLE_NAME: "author", REFERENCED_TABLE_NAME_UP: "Author", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "AuthorRef", REFERENCES: "ID", CONSTRAINT: "OnDelete:CASCADE"},
+					{TABLE_NAME: "book", TABLE_NAME_UP: "Book", REFERENCED_TABLE_NAME: "author", REFERENCED_TABLE_NAME_UP: "Author", RELATION_TYPE: field.Many2Many, MIDDLE_TABLE: "author_book", REFERENCES: "Isbn", JOIN_FOREIGN_KEY: "AuthorRef"},
+				},
+				"book": {
+					{TABLE_NAME: "author_book", TABLE_NAME_UP: "AuthorBook", COLUMN_NAME: "book_isbn", COLUMN_NAME_UP: "BookIsbn", REFERENCED_TABLE_NAME: "book", REFERENCED_TABLE_NAME_UP: "Book", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "BookIsbn", REFERENCES: "Isbn"},
+					{TABLE_NAME: "author", TABLE_NAME_UP: "Author", REFERENCED_TABLE_NAME: "book", REFERENCED_TABLE_NAME_UP: "Book", RELATION_TYPE: field.Many2Many, MIDDLE_TABLE: "author_book", FOREIGN_KEY: "Isbn", JOIN_REFERENCES: "AuthorRef"},
+				},
+			},
+		},
+		{
+			// hasmanyTables中没有配置中间表时外键不生成hasmany关联，连接表模型的关联使用中间表中的虚拟外键
+			name:      "配置了hasmanyTables时使用中间表的虚拟外键",
+			hasmany:   map[string][]string{"author": {"post"}},
+			relations: []common.Results{fk("author_book", "writer_id", "author"), fk("author_book", "book_id", "book")},
+			want: map[string][]common.SubTable{
+				"author": {
+					{TABLE_NAME: "book", TABLE_NAME_UP: "Book", REFERENCED_TABLE_NAME: "author", REFERENCED_TABLE_NAME_UP: "Author", RELATION_TYPE: field.Many2Many, MIDDLE_TABLE: "author_book", JOIN_FOREIGN_KEY: "WriterID"},
+					{TABLE_NAME: "author_book", TABLE_NAME_UP: "AuthorBook", COLUMN_NAME: "writer_id", COLUMN_NAME_UP: "WriterID", REFERENCED_TABLE_NAME: "author", REFERENCED_TABLE_NAME_UP: "Author", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "WriterID", REFERENCES: "ID"},
+				},
+				"book": {
+					{TABLE_NAME: "author", TABLE_NAME_UP: "Author", REFERENCED_TABLE_NAME: "book", REFERENCED_TABLE_NAME_UP: "Book", RELATION_TYPE: field.Many2Many, MIDDLE_TABLE: "author_book", JOIN_REFERENCES: "WriterID"},
+					{TABLE_NAME: "author_book", TABLE_NAME_UP: "AuthorBook", COLUMN_NAME: "book_id", COLUMN_NAME_UP: "BookID", REFERENCED_TABLE_NAME: "book", REFERENCED_TABLE_NAME_UP: "Book", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "BookID", REFERENCES: "ID"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := common.DBConfig{
+				HasmanyTables:   tt.hasmany,
+				Many2manyTables: map[string][]string{"author_book": {"author", "book"}},
+				JoinTableModels: []string{"author_book"},
+			}
+			// 只比较many2many关联和到连接表模型的hasmany关联，中间表的外键本身不属于author、book
+			got := map[string][]common.SubTable{}
+			for table, subTables := range BuildMasterTableMap(config, tt.relations) {
+				for _, subTable := range subTables {
+					if subTable.MIDDLE_TABLE == "author_book" || subTable.TABLE_NAME == "author_book" {
+						got[table] = append(got[table], subTable)
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMasterTableMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckJoinTableModels(t *testing.T) {
+	config := common.DBConfig{
+		Many2manyTables: map[string][]string{"author_book": {"author", "book"}},
+		JoinTableModels: []string{"author_book"},
+	}
+	tests := []struct {
+		name      string
+		relations []common.Results
+		want      common.ValidationErrors
+	}{
+		{
+			name:      "中间表有引用两个表的外键",
+			relations: []common.Results{fk("author_book", "author_id", "author"), fk("author_book", "book_id", "book")},
+			want:      common.ValidationErrors{},
+		},
+		{
+			name:      "中间表缺少外键",
+			relations: []common.Results{fk("author_book", "author_id", "author")},
+			want: common.ValidationErrors{
+				&common.MissingForeignKeyError{Path: "database.joinTableModels[0]", Table: "author_book", ReferencedTable: "book"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckTableRelations(config, tt.relations)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckTableRelations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompositeForeignKey(t *testing.T) {
+	// order_line.(order_no, shop_id) 引用 orders.(no, shop_id)
+	line := func(column, referencedColumn, constraint string) common.Results {
+		return common.Results{TABLE_NAME: "order_line", COLUMN_NAME: column, CONSTRAINT_NAME: constraint, REFERENCED_TABLE_NAME: "orders", REFERENCED_COLUMN_NAME: referencedColumn}
+	}
+	tests := []struct {
+		name      string
+		relations []common.Results
+		want      []common.SubTable
+	}{
+		{
+			name:      "同一个外键约束的多个列合并为一个关联",
+			relations: []common.Results{line("order_no", "no", "fk_line_order"), line("shop_id", "shop_id", "fk_line_order")},
+			want: []common.SubTable{
+				{TABLE_NAME: "order_line", TABLE_NAME_UP: "OrderLine", COLUMN_NAME: "order_no,shop_id", COLUMN_NAME_UP: "OrderNo,ShopID", REFERENCED_TABLE_NAME: "orders", REFERENCED_TABLE_NAME_UP: "Orders", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "OrderNo,ShopID", REFERENCES: "No,ShopID"},
+			},
+		},
+		{
+			name:      "不同的外键约束分别生成关联",
+			relations: []common.Results{line("order_no", "no", "fk_line_order"), line("origin_no", "no", "fk_line_origin")},
+			want: []common.SubTable{
+				{TABLE_NAME: "order_line", TABLE_NAME_UP: "OrderLine", COLUMN_NAME: "order_no", COLUMN_NAME_UP: "OrderNo", REFERENCED_TABLE_NAME: "orders", REFERENCED_TABLE_NAME_UP: "Orders", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "OrderNo", REFERENCES: "No"},
+				{TABLE_NAME: "order_line", TABLE_NAME_UP: "OrderLine", COLUMN_NAME: "origin_no", COLUMN_NAME_UP: "OriginNo", REFERENCED_TABLE_NAME: "orders", REFERENCED_TABLE_NAME_UP: "Orders", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "OriginNo", REFERENCES: "No"},
+			},
+		},
+		{
+			name:      "没有约束名称的外键不合并",
+			relations: []common.Results{line("order_no", "no", ""), line("shop_id", "shop_id", "")},
+			want: []common.SubTable{
+				{TABLE_NAME: "order_line", TABLE_NAME_UP: "OrderLine", COLUMN_NAME: "order_no", COLUMN_NAME_UP: "OrderNo", REFERENCED_TABLE_NAME: "orders", REFERENCED_TABLE_NAME_UP: "Orders", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "OrderNo", REFERENCES: "No"},
+				{TABLE_NAME: "order_line", TABLE_NAME_UP: "OrderLine", COLUMN_NAME: "shop_id", COLUMN_NAME_UP: "ShopID", REFERENCED_TABLE_NAME: "orders", REFERENCED_TABLE_NAME_UP: "Orders", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "ShopID", REFERENCES: "ShopID"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildMasterTableMap(common.DBConfig{}, tt.relations)["orders"]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMasterTableMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelationReferencesAndConstraint(t *testing.T) {
+	// staff.company_code 引用 company.code（唯一列，不是主键）
+	relation := func(deleteRule, updateRule string) []common.Results {
+		return []common.Results{{TABLE_NAME: "staff", COLUMN_NAME: "company_code", CONSTRAINT_NAME: "fk_staff_company", REFERENCED_TABLE_NAME: "company", REFERENCED_COLUMN_NAME: "code", DELETE_RULE: deleteRule, UPDATE_RULE: updateRule}}
+	}
+	tests := []struct {
+		name      string
+		config    common.DBConfig
+		relations []common.Results
+		table     string
+		want      []common.SubTable
+	}{
+		{
+			name:      "hasmany关联引用非主键列并带有外键规则",
+			relations: relation("SET NULL", "CASCADE"),
+			table:     "company",
+			want: []common.SubTable{
+				{TABLE_NAME: "staff", TABLE_NAME_UP: "Staff", COLUMN_NAME: "company_code", COLUMN_NAME_UP: "CompanyCode", REFERENCED_TABLE_NAME: "company", REFERENCED_TABLE_NAME_UP: "Company", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "CompanyCode", REFERENCES: "Code", CONSTRAINT: "OnDelete:SET NULL,OnUpdate:CASCADE"},
+			},
+		},
+		{
+			name:      "NO ACTION规则不生成constraint",
+			relations: relation("NO ACTION", "no action"),
+			table:     "company",
+			want: []common.SubTable{
+				{TABLE_NAME: "staff", TABLE_NAME_UP: "Staff", COLUMN_NAME: "company_code", COLUMN_NAME_UP: "CompanyCode", REFERENCED_TABLE_NAME: "company", REFERENCED_TABLE_NAME_UP: "Company", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "CompanyCode", REFERENCES: "Code"},
+			},
+		},
+		{
+			name:      "belongsto关联的references为关联表中被引用的列",
+			config:    common.DBConfig{BelongstoTables: map[string][]string{"staff": {"company"}}},
+			relations: relation("CASCADE", ""),
+			table:     "staff",
+			want: []common.SubTable{
+				{TABLE_NAME: "company", TABLE_NAME_UP: "Company", COLUMN_NAME: "code", COLUMN_NAME_UP: "Code", REFERENCED_TABLE_NAME: "staff", REFERENCED_TABLE_NAME_UP: "Staff", RELATION_TYPE: field.BelongsTo, FOREIGN_KEY: "CompanyCode", REFERENCES: "Code", CONSTRAINT: "OnDelete:CASCADE"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildMasterTableMap(tt.config, tt.relations)[tt.table]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMasterTableMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelfReference(t *testing.T) {
+	parentID := common.Results{TABLE_NAME: "category", COLUMN_NAME: "parent_id", CONSTRAINT_NAME: "fk_category_parent", REFERENCED_TABLE_NAME: "category", REFERENCED_COLUMN_NAME: "id", DELETE_RULE: "CASCADE"}
+	managerID := common.Results{TABLE_NAME: "category", COLUMN_NAME: "manager_id", CONSTRAINT_NAME: "fk_category_manager", REFERENCED_TABLE_NAME: "category", REFERENCED_COLUMN_NAME: "id"}
+	// 自关联外键生成的belongsto、hasmany两个关联字段，parent、children为两个字段的名称
+	pair := func(column, columnUp, constraint, parent, children string) []common.SubTable {
+		st := common.SubTable{TABLE_NAME: "category", TABLE_NAME_UP: "Category", COLUMN_NAME: column, COLUMN_NAME_UP: columnUp, REFERENCED_TABLE_NAME: "category", REFERENCED_TABLE_NAME_UP: "Category", FOREIGN_KEY: columnUp, REFERENCES: "ID"}
+		belongsTo, hasMany := st, st
+		belongsTo.RELATION_TYPE, belongsTo.FIELD_NAME, belongsTo.POINTER = field.BelongsTo, parent, true
+		hasMany.RELATION_TYPE, hasMany.FIELD_NAME, hasMany.CONSTRAINT = field.HasMany, children, constraint
+		return []common.SubTable{belongsTo, hasMany}
+	}
+	tests := []struct {
+		name      string
+		config    common.DBConfig
+		relations []common.Results
+		want      []common.SubTable
+	}{
+		{
+			name:      "默认生成Parent和Children",
+			relations: []common.Results{parentID},
+			want:      pair("parent_id", "ParentID", "OnDelete:CASCADE", "Parent", "Children"),
+		},
+		{
+			name:      "配置表的关联字段名称",
+			config:    common.DBConfig{SelfReferences: map[string]common.SelfReference{"category": {Parent: "Up", Children: "Subs"}}},
+			relations: []common.Results{parentID},
+			want:      pair("parent_id", "ParentID", "OnDelete:CASCADE", "Up", "Subs"),
+		},
+		{
+			name:      "多个自关联外键根据列名生成名称",
+			config:    common.DBConfig{SelfReferences: map[string]common.SelfReference{"category.manager_id": {Children: "Reports"}}},
+			relations: []common.Results{parentID, managerID},
+			want: append(pair("parent_id", "ParentID", "OnDelete:CASCADE", "Parent", "ParentChildren"),
+				pair("manager_id", "ManagerID", "", "Manager", "Reports")...),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildMasterTableMap(tt.config, tt.relations)["category"]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMasterTableMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelationDepth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	sql := "CREATE TABLE company (id integer PRIMARY KEY);\n" +
+		"CREATE TABLE department (id integer PRIMARY KEY, company_id integer REFERENCES company(id));\n" +
+		"CREATE TABLE staff (id integer PRIMARY KEY, department_id integer REFERENCES department(id));\n" +
+		"CREATE TABLE category (id integer PRIMARY KEY, parent_id integer REFERENCES category(id));"
+	if err := os.WriteFile(path, []byte(sql), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := ddl.Open(path, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	relations := []common.Results{fk("department", "company_id", "company"), fk("staff", "department_id", "department"), fk("category", "parent_id", "category")}
+
+	tests := []struct {
+		name  string
+		table string
+		depth int
+		want  []string // 生成的关联模型，表名#关联模型带有的关联字段层数
+	}{
+		{name: "嵌套1层时关联模型不带关联字段", table: "company", depth: 1, want: []string{"department#0"}},
+		{name: "嵌套2层时关联模型带有自己的关联字段", table: "company", depth: 2, want: []string{"department#1", "staff#0"}},
+		{name: "嵌套层数大于关联层数", table: "company", depth: 3, want: []string{"department#2", "staff#1"}},
+		{name: "自关联在达到嵌套层数后停止", table: "category", depth: 2, want: []string{"category#0", "category#1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := common.DefaultConfig().Database
+			g, fieldOpts := utils.InitGenGenerator(db, config)
+			b := &relationBuilder{g: g, fieldOpts: fieldOpts, masterTableMap: BuildMasterTableMap(config, relations), models: map[string]relateFunc{}}
+			b.relationOpts(tt.table, tt.depth)
+			if got := utils.SortedKeys(b.models); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("relationOpts() models = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// suffixInflector 测试用的复数形式转换，在单词后加上List
+type suffixInflector struct{}
+
+func (suffixInflector) Plural(word string) string {
+	return word + "List"
+}
+
+func TestNameRelations(t *testing.T) {
+	// orders.buyer_id、orders.seller_id 引用 user，user与tag多对多
+	relations := []common.Results{fk("orders", "buyer_id", "user"), fk("orders", "seller_id", "user"), fk("address", "user_id", "user")}
+	many2many := map[string][]string{"user_tag": {"user", "tag"}}
+	tests := []struct {
+		name   string
+		config common.DBConfig
+		want   map[string][]string
+	}{
+		{
+			name:   "默认使用关联表名",
+			config: common.DBConfig{Many2manyTables: many2many},
+			want:   map[string][]string{"user": {"Orders", "Orders", "Address", "Tag"}, "tag": {"User"}},
+		},
+		{
+			name:   "根据外键列名生成并使用复数形式",
+			config: common.DBConfig{Many2manyTables: many2many, RelationNaming: RelationNamingColumn},
+			want:   map[string][]string{"user": {"BuyerOrders", "SellerOrders", "Addresses", "Tags"}, "tag": {"Users"}},
+		},
+		{
+			name:   "使用配置的复数形式转换",
+			config: common.DBConfig{Many2manyTables: many2many, RelationNaming: RelationNamingColumn, Inflector: suffixInflector{}},
+			want:   map[string][]string{"user": {"BuyerOrdersList", "SellerOrdersList", "AddressList", "TagList"}, "tag": {"UserList"}},
+		},
+		{
+			name:   "relationNames不区分大小写覆盖生成的名称",
+			config: common.DBConfig{Many2manyTables: many2many, RelationNaming: RelationNamingColumn, RelationNames: map[string]string{"user.buyerorders": "Purchases", "tag.Users": " Owners "}},
+			want:   map[string][]string{"user": {"Purchases", "SellerOrders", "Addresses", "Tags"}, "tag": {"Owners"}},
+		},
+		{
+			name:   "belongsto关联使用去掉ID后缀的外键字段名",
+			config: common.DBConfig{BelongstoTables: map[string][]string{"orders": {"user"}}, RelationNaming: RelationNamingColumn},
+			want:   map[string][]string{"orders": {"Buyer", "Seller"}, "user": {"Addresses"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masterTableMap := BuildMasterTableMap(tt.config, relations)
+			nameRelations(tt.config, masterTableMap)
+			got := map[string][]string{}
+			for table, subTables := range masterTableMap {
+				for _, subTable := range subTables {
+					got[table] = append(got[table], relationFieldName(subTable))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nameRelations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasmanyTablesAndIgnoreRelations(t *testing.T) {
+	// staff.company_id、audit_log.company_id 引用 company
+	companyStaff := common.Results{TABLE_NAME: "staff", COLUMN_NAME: "company_id", CONSTRAINT_NAME: "fk_staff_company", REFERENCED_TABLE_NAME: "company", REFERENCED_COLUMN_NAME: "id"}
+	companyAudit := common.Results{TABLE_NAME: "audit_log", COLUMN_NAME: "company_id", CONSTRAINT_NAME: "fk_audit_company", REFERENCED_TABLE_NAME: "company", REFERENCED_COLUMN_NAME: "id"}
+	relations := []common.Results{companyStaff, companyAudit}
+	hasMany := func(relation common.Results) common.SubTable {
+		return common.SubTable{TABLE_NAME: relation.TABLE_NAME, TABLE_NAME_UP: utils.Case2Camel(relation.TABLE_NAME), COLUMN_NAME: "company_id", COLUMN_NAME_UP: "CompanyID", REFERENCED_TABLE_NAME: "company", REFERENCED_TABLE_NAME_UP: "Company", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "CompanyID", REFERENCES: "ID"}
+	}
+	tests := []struct {
+		name   string
+		config common.DBConfig
+		want   []common.SubTable
+	}{
+		{
+			name: "没有配置hasmanyTables时每个外键生成hasmany关联",
+			want: []common.SubTable{hasMany(companyStaff), hasMany(companyAudit)},
+		},
+		{
+			name:   "配置了hasmanyTables时只生成配置的hasmany关联",
+			config: common.DBConfig{HasmanyTables: map[string][]string{"company": {"staff"}}},
+			want:   []common.SubTable{hasMany(companyStaff)},
+		},
+		{
+			name:   "按外键约束名称忽略",
+			config: common.DBConfig{IgnoreRelations: []string{"fk_audit_company"}},
+			want:   []common.SubTable{hasMany(companyStaff)},
+		},
+		{
+			name:   "按子表名和关联表名忽略",
+			config: common.DBConfig{IgnoreRelations: []string{"audit_log->company", "staff->department"}},
+			want:   []common.SubTable{hasMany(companyStaff)},
+		},
+		{
+			name:   "忽略全部外键",
+			config: common.DBConfig{IgnoreRelations: []string{"fk_staff_company", "fk_audit_company"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildMasterTableMap(tt.config, relations)["company"]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMasterTableMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBidirectionalRelations(t *testing.T) {
+	// staff.department_id、staff.mentor_department_id 引用 department
+	departmentID := common.Results{TABLE_NAME: "staff", COLUMN_NAME: "department_id", CONSTRAINT_NAME: "fk_staff_department", REFERENCED_TABLE_NAME: "department", REFERENCED_COLUMN_NAME: "id", DELETE_RULE: "SET NULL"}
+	mentorID := common.Results{TABLE_NAME: "staff", COLUMN_NAME: "mentor_department_id", CONSTRAINT_NAME: "fk_staff_mentor", REFERENCED_TABLE_NAME: "department", REFERENCED_COLUMN_NAME: "id"}
+	belongsTo := func(columnUp string, pointer bool) common.SubTable {
+		return common.SubTable{TABLE_NAME: "department", TABLE_NAME_UP: "Department", COLUMN_NAME: "id", COLUMN_NAME_UP: "ID", REFERENCED_TABLE_NAME: "staff", REFERENCED_TABLE_NAME_UP: "Staff", RELATION_TYPE: field.BelongsTo, FOREIGN_KEY: columnUp, REFERENCES: "ID", POINTER: pointer}
+	}
+	inverse := func(relationType field.RelationshipType, column, columnUp, constraint string) common.SubTable {
+		return common.SubTable{TABLE_NAME: "staff", TABLE_NAME_UP: "Staff", COLUMN_NAME: column, COLUMN_NAME_UP: columnUp, REFERENCED_TABLE_NAME: "department", REFERENCED_TABLE_NAME_UP: "Department", RELATION_TYPE: relationType, FOREIGN_KEY: columnUp, REFERENCES: "ID", CONSTRAINT: constraint}
+	}
+	tests := []struct {
+		name      string
+		relations []common.BidirectionalRelation
+		want      map[string][]common.SubTable
+	}{
+		{
+			name:      "默认生成belongsto和hasmany，外键约束只在hasmany一侧生成",
+			relations: []common.BidirectionalRelation{{Table: "staff", ReferencedTable: "department", Column: "department_id"}},
+			want: map[string][]common.SubTable{
+				"staff":      {belongsTo("DepartmentID", false)},
+				"department": {inverse(field.HasMany, "department_id", "DepartmentID", "OnDelete:SET NULL"), inverse(field.HasMany, "mentor_department_id", "MentorDepartmentID", "")},
+			},
+		},
+		{
+			name:      "hasone时belongsto字段使用指针",
+			relations: []common.BidirectionalRelation{{Table: "staff", ReferencedTable: "department", Column: "department_id", Inverse: "HasOne"}},
+			want: map[string][]common.SubTable{
+				"staff":      {belongsTo("DepartmentID", true)},
+				"department": {inverse(field.HasOne, "department_id", "DepartmentID", "OnDelete:SET NULL"), inverse(field.HasMany, "mentor_department_id", "MentorDepartmentID", "")},
+			},
+		},
+		{
+			name:      "没有配置列时匹配引用关联表的所有外键",
+			relations: []common.BidirectionalRelation{{Table: "staff", ReferencedTable: "department"}},
+			want: map[string][]common.SubTable{
+				"staff":      {belongsTo("DepartmentID", false), belongsTo("MentorDepartmentID", false)},
+				"department": {inverse(field.HasMany, "department_id", "DepartmentID", "OnDelete:SET NULL"), inverse(field.HasMany, "mentor_department_id", "MentorDepartmentID", "")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildMasterTableMap(common.DBConfig{BidirectionalRelations: tt.relations}, []common.Results{departmentID, mentorID})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMasterTableMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaQualifiedRelations(t *testing.T) {
+	// sales.order.customer_id 引用 crm.customer，hr.staff.manager_id、hr.staff.mentor_id 引用 hr.staff
+	orderCustomer := common.Results{TABLE_NAME: "sales.order", COLUMN_NAME: "customer_id", CONSTRAINT_NAME: "fk_order_customer", REFERENCED_TABLE_NAME: "crm.customer", REFERENCED_COLUMN_NAME: "id"}
+	staffManager := common.Results{TABLE_NAME: "hr.staff", COLUMN_NAME: "manager_id", CONSTRAINT_NAME: "fk_staff_manager", REFERENCED_TABLE_NAME: "hr.staff", REFERENCED_COLUMN_NAME: "id"}
+	staffMentor := common.Results{TABLE_NAME: "hr.staff", COLUMN_NAME: "mentor_id", CONSTRAINT_NAME: "fk_staff_mentor", REFERENCED_TABLE_NAME: "hr.staff", REFERENCED_COLUMN_NAME: "id"}
+	// hr.staff的自关联外键生成的belongsto、hasmany两个关联字段
+	selfPair := func(column, columnUp, parent, children string) []common.SubTable {
+		st := common.SubTable{TABLE_NAME: "hr.staff", TABLE_NAME_UP: "HrStaff", COLUMN_NAME: column, COLUMN_NAME_UP: columnUp, REFERENCED_TABLE_NAME: "hr.staff", REFERENCED_TABLE_NAME_UP: "HrStaff", FOREIGN_KEY: columnUp, REFERENCES: "ID"}
+		belongsTo, hasMany := st, st
+		belongsTo.RELATION_TYPE, belongsTo.FIELD_NAME, belongsTo.POINTER = field.BelongsTo, parent, true
+		hasMany.RELATION_TYPE, hasMany.FIELD_NAME = field.HasMany, children
+		return []common.SubTable{belongsTo, hasMany}
+	}
+	tests := []struct {
+		name      string
+		config    common.DBConfig
+		relations []common.Results
+		want      map[string][]common.SubTable
+	}{
+		{
+			name:      "跨schema的外键生成hasmany关联，模型名称带有schema名",
+			relations: []common.Results{orderCustomer},
+			want: map[string][]common.SubTable{
+				"crm.customer": {{TABLE_NAME: "sales.order", TABLE_NAME_UP: "SalesOrder", COLUMN_NAME: "customer_id", COLUMN_NAME_UP: "CustomerID", REFERENCED_TABLE_NAME: "crm.customer", REFERENCED_TABLE_NAME_UP: "CrmCustomer", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "CustomerID", REFERENCES: "ID"}},
+			},
+		},
+		{
+			name:      "使用 schema名.表名 配置belongsto关联",
+			config:    common.DBConfig{Tables: []string{"sales.order", "crm.customer"}, BelongstoTables: map[string][]string{"sales.order": {"crm.customer"}}},
+			relations: []common.Results{orderCustomer, staffManager},
+			want: map[string][]common.SubTable{
+				"sales.order": {{TABLE_NAME: "crm.customer", TABLE_NAME_UP: "CrmCustomer", COLUMN_NAME: "id", COLUMN_NAME_UP: "ID", REFERENCED_TABLE_NAME: "sales.order", REFERENCED_TABLE_NAME_UP: "SalesOrder", RELATION_TYPE: field.BelongsTo, FOREIGN_KEY: "CustomerID", REFERENCES: "ID"}},
+			},
+		},
+		{
+			name:      "使用 schema名.表名 配置自关联字段名称",
+			config:    common.DBConfig{SelfReferences: map[string]common.SelfReference{"hr.staff": {Parent: "Manager", Children: "Reports"}}},
+			relations: []common.Results{staffManager},
+			want:      map[string][]common.SubTable{"hr.staff": selfPair("manager_id", "ManagerID", "Manager", "Reports")},
+		},
+		{
+			name:      "自关联表有多个外键时使用 schema名.表名.列名 配置名称",
+			config:    common.DBConfig{SelfReferences: map[string]common.SelfReference{"hr.staff.manager_id": {Children: "Reports"}}},
+			relations: []common.Results{staffManager, staffMentor},
+			want: map[string][]common.SubTable{
+				"hr.staff": append(selfPair("manager_id", "ManagerID", "Manager", "Reports"), selfPair("mentor_id", "MentorID", "Mentor", "MentorChildren")...),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildMasterTableMap(tt.config, tt.relations)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMasterTableMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaTables(t *testing.T) {
+	// billing.invoice.customer_id 引用 crm.customer，billing.invoice_line.invoice_id 引用 billing.invoice
+	relations := []common.Results{
+		{TABLE_NAME: "billing.invoice", COLUMN_NAME: "customer_id", CONSTRAINT_NAME: "fk_invoice_customer", REFERENCED_TABLE_NAME: "crm.customer", REFERENCED_COLUMN_NAME: "id"},
+		{TABLE_NAME: "billing.invoice_line", COLUMN_NAME: "invoice_id", CONSTRAINT_NAME: "fk_line_invoice", REFERENCED_TABLE_NAME: "billing.invoice", REFERENCED_COLUMN_NAME: "id"},
+	}
+	config := common.DBConfig{BelongstoTables: map[string][]string{"billing.invoice": {"crm.customer"}}}
+	tables := []string{"billing.invoice", "billing.invoice_line", "crm.customer", "crm.contact"}
+	masterTableMap := BuildMasterTableMap(config, relations)
+
+	wantSubTables := map[string][]common.SubTable{
+		"billing.invoice": {
+			{TABLE_NAME: "crm.customer", TABLE_NAME_UP: "CrmCustomer", COLUMN_NAME: "id", COLUMN_NAME_UP: "ID", REFERENCED_TABLE_NAME: "billing.invoice", REFERENCED_TABLE_NAME_UP: "BillingInvoice", RELATION_TYPE: field.BelongsTo, FOREIGN_KEY: "CustomerID", REFERENCES: "ID"},
+			{TABLE_NAME: "billing.invoice_line", TABLE_NAME_UP: "BillingInvoiceLine", COLUMN_NAME: "invoice_id", COLUMN_NAME_UP: "InvoiceID", REFERENCED_TABLE_NAME: "billing.invoice", REFERENCED_TABLE_NAME_UP: "BillingInvoice", RELATION_TYPE: field.HasMany, FOREIGN_KEY: "InvoiceID", REFERENCES: "ID"},
+		},
+	}
+	if !reflect.DeepEqual(masterTableMap, wantSubTables) {
+		t.Errorf("BuildMasterTableMap() = %+v, want %+v", masterTableMap, wantSubTables)
+	}
+
+	db := &gorm.DB{Config: &gorm.Config{NamingStrategy: schema.NamingStrategy{SingularTable: true}}}
+	tests := []struct {
+		schema         string
+		wantTables     []string
+		wantReferenced []string
+		wantModelNames []string // tables中每个表的模型名称
+	}{
+		{
+			schema:         "billing",
+			wantTables:     []string{"billing.invoice", "billing.invoice_line"},
+			wantReferenced: []string{"crm.customer"},
+			wantModelNames: []string{"Invoice", "InvoiceLine", "CrmCustomer", "CrmContact"},
+		},
+		{
+			schema:         "crm",
+			wantTables:     []string{"crm.customer", "crm.contact"},
+			wantModelNames: []string{"BillingInvoice", "BillingInvoiceLine", "Customer", "Contact"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			gotTables, gotReferenced := schemaTables(tables, masterTableMap, tt.schema)
+			if !reflect.DeepEqual(gotTables, tt.wantTables) || !reflect.DeepEqual(gotReferenced, tt.wantReferenced) {
+				t.Errorf("schemaTables() = %v, %v, want %v, %v", gotTables, gotReferenced, tt.wantTables, tt.wantReferenced)
+			}
+			modelName := schemaModelName(db, tt.schema)
+			gotNames := []string{}
+			for _, table := range tables {
+				gotNames = append(gotNames, modelName(table))
+			}
+			if !reflect.DeepEqual(gotNames, tt.wantModelNames) {
+				t.Errorf("schemaModelName() = %v, want %v", gotNames, tt.wantModelNames)
+			}
+		})
+	}
+}
+
+func TestPolymorphicRelations(t *testing.T) {
+	polymorphic := func(table, owner, prefix, value string, relationType field.RelationshipType) common.SubTable {
+		return common.SubTable{TABLE_NAME: table, TABLE_NAME_UP: utils.Case2Camel(table), COLUMN_NAME: prefix + "_id", COLUMN_NAME_UP: utils.FieldNames(prefix + "_id"), REFERENCED_TABLE_NAME: owner, REFERENCED_TABLE_NAME_UP: utils.Case2Camel(owner), RELATION_TYPE: relationType, POLYMORPHIC: utils.Case2Camel(prefix), POLYMORPHIC_VALUE: value}
+	}
+	tests := []struct {
+		name      string
+		relations []common.PolymorphicRelation
+		want      map[string][]common.SubTable
+	}{
+		{
+			name:      "每个拥有者表生成hasmany关联",
+			relations: []common.PolymorphicRelation{{Table: "comment", Polymorphic: "commentable", Owners: []string{"post", "video"}}},
+			want: map[string][]common.SubTable{
+				"post":  {polymorphic("comment", "post", "commentable", "post", field.HasMany)},
+				"video": {polymorphic("comment", "video", "commentable", "video", field.HasMany)},
+			},
+		},
+		{
+			name:      "默认多态列名前缀和配置的类型值",
+			relations: []common.PolymorphicRelation{{Table: "tag", Owners: []string{"post", "video"}, Values: map[string]string{"post": "posts"}}},
+			want: map[string][]common.SubTable{
+				"post":  {polymorphic("tag", "post", "tagable", "posts", field.HasMany)},
+				"video": {polymorphic("tag", "video", "tagable", "video", field.HasMany)},
+			},
+		},
+		{
+			name:      "生成hasone关联",
+			relations: []common.PolymorphicRelation{{Table: "cover", Polymorphic: "owner", Owners: []string{"post"}, HasOne: true}},
+			want: map[string][]common.SubTable{
+				"post": {polymorphic("cover", "post", "owner", "post", field.HasOne)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildMasterTableMap(common.DBConfig{PolymorphicRelations: tt.relations}, nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildMasterTableMap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
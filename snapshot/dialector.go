@@ -0,0 +1,263 @@
+package snapshot
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// Open 返回使用快照代替数据库的连接，gen通过它读取表名、列信息和索引信息，不会连接任何数据库
+func Open(s *Snapshot, config *gorm.Config) (*gorm.DB, error) {
+	return gorm.Open(dialector{snapshot: s}, config)
+}
+
+// dialector 使用快照实现的gorm.Dialector，Name返回生成快照时的数据库驱动，使gen按照相同的规则生成字段类型
+type dialector struct {
+	snapshot *Snapshot
+}
+
+func (d dialector) Name() string {
+	return d.snapshot.DbDriver
+}
+
+func (d dialector) Initialize(*gorm.DB) error {
+	return nil
+}
+
+func (d dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return snapshotMigrator{
+		Migrator: migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}},
+		snapshot: d.snapshot,
+	}
+}
+
+func (d dialector) DataTypeOf(field *schema.Field) string {
+	return string(field.DataType)
+}
+
+func (d dialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+func (d dialector) BindVarTo(writer clause.Writer, _ *gorm.Statement, _ interface{}) {
+	writer.WriteByte('?')
+}
+
+func (d dialector) QuoteTo(writer clause.Writer, str string) {
+	writer.WriteString(str)
+}
+
+func (d dialector) Explain(sql string, vars ...interface{}) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
+
+// snapshotMigrator 从快照中读取表名、列信息和索引信息
+type snapshotMigrator struct {
+	migrator.Migrator
+	snapshot *Snapshot
+}
+
+func (m snapshotMigrator) CurrentDatabase() string {
+	return m.snapshot.DbName
+}
+
+func (m snapshotMigrator) GetTables() ([]string, error) {
+	tables := make([]string, 0, len(m.snapshot.Tables))
+	for _, table := range m.snapshot.Tables {
+		tables = append(tables, table.Name)
+	}
+	return tables, nil
+}
+
+func (m snapshotMigrator) HasTable(value interface{}) bool {
+	name, ok := value.(string)
+	if !ok {
+		return false
+	}
+	_, ok = m.snapshot.table(name)
+	return ok
+}
+
+func (m snapshotMigrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
+	table, err := m.lookup(value)
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := make([]gorm.ColumnType, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		t, err := scanType(column.ScanType)
+		if err != nil {
+			return nil, err
+		}
+		columnTypes = append(columnTypes, columnType{column: column, scanType: t})
+	}
+	return columnTypes, nil
+}
+
+func (m snapshotMigrator) GetIndexes(value interface{}) ([]gorm.Index, error) {
+	table, err := m.lookup(value)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]gorm.Index, 0, len(table.Indexes))
+	for _, index := range table.Indexes {
+		indexes = append(indexes, tableIndex{table: table.Name, index: index})
+	}
+	return indexes, nil
+}
+
+// lookup 根据表名查找快照中的表结构
+func (m snapshotMigrator) lookup(value interface{}) (Table, error) {
+	name, ok := value.(string)
+	if !ok {
+		return Table{}, fmt.Errorf("快照只支持通过表名读取表结构，不支持 %T", value)
+	}
+	table, ok := m.snapshot.table(name)
+	if !ok {
+		return Table{}, fmt.Errorf("快照中不存在表 %s，请重新执行 inspect 生成快照", name)
+	}
+	return table, nil
+}
+
+// columnType 使用快照中的列信息实现gorm.ColumnType
+type columnType struct {
+	column   Column
+	scanType reflect.Type
+}
+
+func (c columnType) Name() string {
+	return c.column.Name
+}
+
+func (c columnType) DatabaseTypeName() string {
+	return c.column.DatabaseTypeName
+}
+
+func (c columnType) ColumnType() (string, bool) {
+	return stringValue(c.column.ColumnType)
+}
+
+func (c columnType) PrimaryKey() (bool, bool) {
+	return boolValue(c.column.PrimaryKey)
+}
+
+func (c columnType) AutoIncrement() (bool, bool) {
+	return boolValue(c.column.AutoIncrement)
+}
+
+func (c columnType) Length() (int64, bool) {
+	if c.column.Length == nil {
+		return 0, false
+	}
+	return *c.column.Length, true
+}
+
+func (c columnType) DecimalSize() (int64, int64, bool) {
+	if c.column.Precision == nil || c.column.Scale == nil {
+		return 0, 0, false
+	}
+	return *c.column.Precision, *c.column.Scale, true
+}
+
+func (c columnType) Nullable() (bool, bool) {
+	return boolValue(c.column.Nullable)
+}
+
+func (c columnType) Unique() (bool, bool) {
+	return boolValue(c.column.Unique)
+}
+
+func (c columnType) ScanType() reflect.Type {
+	return c.scanType
+}
+
+func (c columnType) Comment() (string, bool) {
+	return stringValue(c.column.Comment)
+}
+
+func (c columnType) DefaultValue() (string, bool) {
+	return stringValue(c.column.DefaultValue)
+}
+
+// tableIndex 使用快照中的索引信息实现gorm.Index
+type tableIndex struct {
+	table string
+	index Index
+}
+
+func (i tableIndex) Table() string {
+	return i.table
+}
+
+func (i tableIndex) Name() string {
+	return i.index.Name
+}
+
+func (i tableIndex) Columns() []string {
+	return i.index.Columns
+}
+
+func (i tableIndex) PrimaryKey() (bool, bool) {
+	return boolValue(i.index.PrimaryKey)
+}
+
+func (i tableIndex) Unique() (bool, bool) {
+	return boolValue(i.index.Unique)
+}
+
+func (i tableIndex) Option() string {
+	return i.index.Option
+}
+
+func stringValue(value *string) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	return *value, true
+}
+
+func boolValue(value *bool) (bool, bool) {
+	if value == nil {
+		return false, false
+	}
+	return *value, true
+}
+
+// scanTypes 数据库驱动扫描列时可能使用的Go类型，key为reflect.Type.String()的结果
+var scanTypes = func() map[string]reflect.Type {
+	types := map[string]reflect.Type{}
+	for _, value := range []interface{}{
+		false, "", []byte(nil), sql.RawBytes(nil), new(interface{}), time.Time{},
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0),
+		sql.NullBool{}, sql.NullByte{}, sql.NullInt16{}, sql.NullInt32{}, sql.NullInt64{},
+		sql.NullFloat64{}, sql.NullString{}, sql.NullTime{},
+	} {
+		t := reflect.TypeOf(value)
+		types[t.String()] = t
+	}
+	// interface{} 类型只能通过指针获取
+	t := reflect.TypeOf(new(interface{})).Elem()
+	types[t.String()] = t
+	return types
+}()
+
+// scanType 根据快照中记录的类型名称返回对应的Go类型，空字符串表示数据库驱动没有提供扫描类型
+func scanType(name string) (reflect.Type, error) {
+	if name == "" {
+		return nil, nil
+	}
+	t, ok := scanTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("无法识别的列扫描类型 %s", name)
+	}
+	return t, nil
+}
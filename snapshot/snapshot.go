@@ -0,0 +1,197 @@
+// Package snapshot 将数据库表结构保存为JSON快照文件，并可以在没有数据库连接的情况下，
+// 使用快照代替数据库生成与直接连接数据库完全相同的代码。
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/essrt/gentoolplus/common"
+	"gorm.io/gorm"
+)
+
+// FormatVersion 快照文件格式的版本号，格式不兼容时递增
+const FormatVersion = 1
+
+// Snapshot 数据库表结构快照
+type Snapshot struct {
+	Version    int              `json:"version"`    // 快照文件格式的版本号
+	DbDriver   string           `json:"dbDriver"`   // 生成快照时使用的数据库类型，即gorm Dialector的名称
+	DbName     string           `json:"dbName"`     // 数据库名称
+	TableNames []string         `json:"tableNames"` // 数据库中所有的表名，用于检查配置文件中的表名
+	Tables     []Table          `json:"tables"`     // 需要生成代码的表结构
+	Relations  []common.Results `json:"relations"`  // 表之间的外键关联关系
+}
+
+// Table 表结构
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+	Indexes []Index  `json:"indexes,omitempty"`
+}
+
+// Column 列信息，指针类型的字段为nil表示数据库驱动没有提供该信息
+type Column struct {
+	Name             string  `json:"name"`
+	DatabaseTypeName string  `json:"databaseTypeName"`        // 如 varchar
+	ColumnType       *string `json:"columnType,omitempty"`    // 如 varchar(64)
+	PrimaryKey       *bool   `json:"primaryKey,omitempty"`    // 是否主键
+	AutoIncrement    *bool   `json:"autoIncrement,omitempty"` // 是否自增
+	Length           *int64  `json:"length,omitempty"`        // 长度
+	Precision        *int64  `json:"precision,omitempty"`     // 精度，与Scale同时存在
+	Scale            *int64  `json:"scale,omitempty"`         // 小数位数
+	Nullable         *bool   `json:"nullable,omitempty"`      // 是否可以为null
+	Unique           *bool   `json:"unique,omitempty"`        // 是否唯一
+	ScanType         string  `json:"scanType,omitempty"`      // 数据库驱动扫描该列使用的Go类型，如 sql.NullInt64
+	Comment          *string `json:"comment,omitempty"`       // 注释
+	DefaultValue     *string `json:"defaultValue,omitempty"`  // 默认值
+}
+
+// Index 索引信息
+type Index struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	PrimaryKey *bool    `json:"primaryKey,omitempty"`
+	Unique     *bool    `json:"unique,omitempty"`
+	Option     string   `json:"option,omitempty"`
+}
+
+// Capture 读取数据库中的表结构生成快照，tableNames为数据库中所有的表名，relationList为表之间的外键关联关系，
+// 配置了tables时只保存tables中的表结构，否则保存gen能够读取到的所有表结构
+func Capture(db *gorm.DB, config common.DBConfig, tableNames []string, relationList []common.Results) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Version:    FormatVersion,
+		DbDriver:   db.Dialector.Name(), // ddl模式下为sqlite，离线生成时gen按实际的数据库类型处理列类型
+		DbName:     config.DbName,
+		TableNames: tableNames,
+		Relations:  relationList,
+	}
+
+	tables := config.Tables
+	if len(tables) == 0 && len(config.Schemas) > 0 {
+		// 配置了schemas时，gorm只能读取默认schema中的表名
+		tables = tableNames
+	} else if len(tables) == 0 {
+		var err error
+		if tables, err = db.Migrator().GetTables(); err != nil {
+			return nil, fmt.Errorf("查询数据库表名失败: %w", err)
+		}
+	}
+
+	for _, tableName := range tables {
+		columnTypes, err := db.Migrator().ColumnTypes(tableName)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 的列信息失败: %w", tableName, err)
+		}
+		table := Table{Name: tableName}
+		for _, columnType := range columnTypes {
+			table.Columns = append(table.Columns, newColumn(columnType))
+		}
+		// 部分数据库驱动不支持读取索引，gen在这种情况下同样忽略索引
+		if indexes, err := db.Migrator().GetIndexes(tableName); err == nil {
+			for _, index := range indexes {
+				table.Indexes = append(table.Indexes, newIndex(index))
+			}
+		}
+		snapshot.Tables = append(snapshot.Tables, table)
+	}
+	return snapshot, nil
+}
+
+// Load 读取快照文件
+func Load(filename string) (*Snapshot, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取快照文件失败: %w", err)
+	}
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(content, snapshot); err != nil {
+		return nil, fmt.Errorf("解析快照文件 %s 失败: %w", filename, err)
+	}
+	if snapshot.Version != FormatVersion {
+		return nil, fmt.Errorf("快照文件 %s 的版本 %d 不受支持，当前支持的版本为 %d，请重新执行 inspect 生成快照", filename, snapshot.Version, FormatVersion)
+	}
+	for _, table := range snapshot.Tables {
+		for _, column := range table.Columns {
+			if _, err := scanType(column.ScanType); err != nil {
+				return nil, fmt.Errorf("快照文件 %s 中表 %s 的列 %s: %w", filename, table.Name, column.Name, err)
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// Save 将快照保存到文件
+func (s *Snapshot) Save(filename string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(content, '\n'), 0644)
+}
+
+// table 返回快照中的表结构
+func (s *Snapshot) table(name string) (Table, bool) {
+	for _, table := range s.Tables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+	return Table{}, false
+}
+
+// newColumn 将数据库驱动返回的列信息转换为快照中的列信息
+func newColumn(columnType gorm.ColumnType) Column {
+	column := Column{
+		Name:             columnType.Name(),
+		DatabaseTypeName: columnType.DatabaseTypeName(),
+	}
+	if value, ok := columnType.ColumnType(); ok {
+		column.ColumnType = &value
+	}
+	if value, ok := columnType.PrimaryKey(); ok {
+		column.PrimaryKey = &value
+	}
+	if value, ok := columnType.AutoIncrement(); ok {
+		column.AutoIncrement = &value
+	}
+	if value, ok := columnType.Length(); ok {
+		column.Length = &value
+	}
+	if precision, scale, ok := columnType.DecimalSize(); ok {
+		column.Precision, column.Scale = &precision, &scale
+	}
+	if value, ok := columnType.Nullable(); ok {
+		column.Nullable = &value
+	}
+	if value, ok := columnType.Unique(); ok {
+		column.Unique = &value
+	}
+	if t := columnType.ScanType(); t != nil {
+		column.ScanType = t.String()
+	}
+	if value, ok := columnType.Comment(); ok {
+		column.Comment = &value
+	}
+	if value, ok := columnType.DefaultValue(); ok {
+		column.DefaultValue = &value
+	}
+	return column
+}
+
+// newIndex 将数据库驱动返回的索引信息转换为快照中的索引信息
+func newIndex(index gorm.Index) Index {
+	i := Index{
+		Name:    index.Name(),
+		Columns: index.Columns(),
+		Option:  index.Option(),
+	}
+	if value, ok := index.PrimaryKey(); ok {
+		i.PrimaryKey = &value
+	}
+	if value, ok := index.Unique(); ok {
+		i.Unique = &value
+	}
+	return i
+}
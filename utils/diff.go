@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffLine 比较结果中的一行，op为 ' '（相同）、'-'（删除）、'+'（新增）
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// SplitLines 将文本按行拆分，末尾的换行符不产生空行
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// UnifiedDiff 返回oldText与newText的unified diff格式差异，以及新增和删除的行数，内容相同时返回空字符串，
+// 差异和行数来自同一次比较
+func UnifiedDiff(oldName, newName, oldText, newText string) (diff string, added, removed int) {
+	if oldText == newText {
+		return "", 0, 0
+	}
+	lines := diffLines(SplitLines(oldText), SplitLines(newText))
+	for _, l := range lines {
+		switch l.op {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	const context = 3
+	for start := 0; start < len(lines); {
+		// 找到下一处差异
+		for start < len(lines) && lines[start].op == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+		// 差异之间相同的行不超过2倍上下文时合并为同一个hunk
+		end := start
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			same := end
+			for same < len(lines) && lines[same].op == ' ' {
+				same++
+			}
+			if same == len(lines) || same-end > 2*context {
+				break
+			}
+			end = same
+		}
+
+		from := max(start-context, 0)
+		to := min(end+context, len(lines))
+		oldStart, newStart := 1, 1
+		for _, l := range lines[:from] {
+			if l.op != '+' {
+				oldStart++
+			}
+			if l.op != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, l := range lines[from:to] {
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, l := range lines[from:to] {
+			b.WriteByte(l.op)
+			b.WriteString(l.text)
+			b.WriteByte('\n')
+		}
+		start = end
+	}
+	return b.String(), added, removed
+}
+
+// diffLines 基于最长公共子序列逐行比较，先去掉相同的首尾行以减少计算量，
+// 中间部分使用Hirschberg算法，内存占用与行数成正比，同一处修改中删除的行排在新增的行之前
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]diffLine, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		result = append(result, diffLine{' ', text})
+	}
+	result = hirschberg(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], result)
+	for _, text := range a[len(a)-suffix:] {
+		result = append(result, diffLine{' ', text})
+	}
+
+	// 连续修改的行中，删除的行排在新增的行之前
+	for start := 0; start < len(result); {
+		if result[start].op == ' ' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(result) && result[end].op != ' ' {
+			end++
+		}
+		changed := result[start:end]
+		sort.SliceStable(changed, func(i, j int) bool {
+			return changed[i].op == '-' && changed[j].op == '+'
+		})
+		start = end
+	}
+	return result
+}
+
+// hirschberg 将a、b的最长公共子序列比较结果追加到result中：把a从中间分为两半，
+// 找到使两半的公共子序列长度之和最大的b的分割位置后分别递归比较
+func hirschberg(a, b []string, result []diffLine) []diffLine {
+	switch {
+	case len(a) == 0:
+		for _, text := range b {
+			result = append(result, diffLine{'+', text})
+		}
+		return result
+	case len(b) == 0:
+		for _, text := range a {
+			result = append(result, diffLine{'-', text})
+		}
+		return result
+	case len(a) == 1:
+		for j, text := range b {
+			if text == a[0] {
+				for _, added := range b[:j] {
+					result = append(result, diffLine{'+', added})
+				}
+				result = append(result, diffLine{' ', text})
+				for _, added := range b[j+1:] {
+					result = append(result, diffLine{'+', added})
+				}
+				return result
+			}
+		}
+		result = append(result, diffLine{'-', a[0]})
+		for _, text := range b {
+			result = append(result, diffLine{'+', text})
+		}
+		return result
+	}
+
+	mid := len(a) / 2
+	left := lcsLengths(a[:mid], b, false)
+	right := lcsLengths(a[mid:], b, true)
+	split, best := 0, -1
+	for j := 0; j <= len(b); j++ {
+		if n := left[j] + right[len(b)-j]; n > best {
+			split, best = j, n
+		}
+	}
+	result = hirschberg(a[:mid], b[:split], result)
+	return hirschberg(a[mid:], b[split:], result)
+}
+
+// lcsLengths 返回a与b的每个前缀（reverse为true时为每个后缀）的最长公共子序列长度，
+// row[j]为a与b的前j行（或后j行）的长度，只保留一行计算结果
+func lcsLengths(a, b []string, reverse bool) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		x := a[i]
+		if reverse {
+			x = a[len(a)-1-i]
+		}
+		for j := range b {
+			y := b[j]
+			if reverse {
+				y = b[len(b)-1-j]
+			}
+			if x == y {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name             string
+		oldText, newText string
+		want             string
+		added, removed   int
+	}{
+		{
+			name:    "内容相同",
+			oldText: "a\nb\n",
+			newText: "a\nb\n",
+			want:    "",
+		},
+		{
+			name:    "新增文件",
+			oldText: "",
+			newText: "a\nb\n",
+			want:    "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+			added:   2,
+		},
+		{
+			name:    "删除文件",
+			oldText: "a\nb\n",
+			newText: "",
+			want:    "--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+			removed: 2,
+		},
+		{
+			name:    "修改一行保留3行上下文",
+			oldText: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			newText: "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want:    "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+			added:   1,
+			removed: 1,
+		},
+		{
+			name:    "相距较远的修改分为两个hunk",
+			oldText: "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			newText: "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n" +
+				"@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+			added:   2,
+			removed: 2,
+		},
+		{
+			name:    "相距较近的修改合并为一个hunk",
+			oldText: "a\n1\n2\nb\n",
+			newText: "a\n1\nx\n2\n",
+			want:    "--- old\n+++ new\n@@ -1,4 +1,4 @@\n a\n 1\n+x\n 2\n-b\n",
+			added:   1,
+			removed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, added, removed := UnifiedDiff("old", "new", tt.oldText, tt.newText)
+			if diff != tt.want {
+				t.Errorf("diff =\n%s\nwant\n%s", diff, tt.want)
+			}
+			if added != tt.added || removed != tt.removed {
+				t.Errorf("added, removed = %d, %d, want %d, %d", added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"都为空", "", ""},
+		{"旧内容为空", "", "a b c"},
+		{"新内容为空", "a b c", ""},
+		{"没有公共行", "a b c", "x y z"},
+		{"插入和删除", "a b c d e f", "a x c d y f z"},
+		{"重复行", "a a b a a", "a b a b a"},
+		{"整体移动", "a b c d e", "c d e a b"},
+		{"较长的内容", strings.Repeat("a b c ", 30), strings.Repeat("b a c ", 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			lines := diffLines(a, b)
+
+			oldLines, newLines, same := []string{}, []string{}, 0
+			for i, l := range lines {
+				if l.op != '+' {
+					oldLines = append(oldLines, l.text)
+				}
+				if l.op != '-' {
+					newLines = append(newLines, l.text)
+				}
+				if l.op == ' ' {
+					same++
+				}
+				// 同一处修改中删除的行排在新增的行之前
+				if i > 0 && lines[i-1].op == '+' && l.op == '-' {
+					t.Errorf("第%d行：删除的行排在了新增的行之后", i)
+				}
+			}
+			if strings.Join(oldLines, " ") != strings.Join(a, " ") {
+				t.Errorf("旧内容 = %v, want %v", oldLines, a)
+			}
+			if strings.Join(newLines, " ") != strings.Join(b, " ") {
+				t.Errorf("新内容 = %v, want %v", newLines, b)
+			}
+			if want := lcsLength(a, b); same != want {
+				t.Errorf("相同的行数 = %d, want %d", same, want)
+			}
+		})
+	}
+}
+
+// lcsLength 使用完整的二维表计算最长公共子序列长度，用于检查diffLines的结果是最短差异
+func lcsLength(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
@@ -1,50 +1,63 @@
 package utils
 
 import (
-	"github.com/essrt/gentoolplus/global"
+	"strings"
+
+	"github.com/essrt/gentoolplus/common"
 	"gorm.io/gen"
 	"gorm.io/gen/field"
 	"gorm.io/gorm"
 )
 
-func InitGenGenerator() (g *gen.Generator, fieldOpts []gen.ModelOpt) {
+func InitGenGenerator(db *gorm.DB, config common.DBConfig) (g *gen.Generator, fieldOpts []gen.ModelOpt) {
 	// 生成实例
 	g = gen.NewGenerator(gen.Config{
 		// 相对执行`go run`时的路径, 会自动创建目录，相对路径为工程根目录
-		OutPath: *global.OutPath,
-		OutFile: *global.OutFile,
+		OutPath: config.OutPath,
+		OutFile: config.OutFile,
 		// WithDefaultQuery 生成默认查询结构体(作为全局变量使用), 即`Q`结构体和其字段(各表模型)
 		// WithoutContext 生成没有context调用限制的代码供查询
 		// WithQueryInterface 生成interface形式的查询代码(可导出), 如`Where()`方法返回的就是一个可导出的接口类型
 		Mode: gen.WithoutContext | gen.WithDefaultQuery | gen.WithQueryInterface,
 
 		// 表字段可为 null 值时, 对应结体字段使用指针类型
-		FieldNullable: global.Config.Database.FieldNullable,
+		FieldNullable: config.FieldNullable,
 
 		// 表字段默认值与模型结构体字段零值不一致的字段, 在插入数据时需要赋值该字段值为零值的, 结构体字段须是指针类型才能成功, 即`FieldCoverable:true`配置下生成的结构体字段.
 		// 因为在插入时遇到字段为零值的会被GORM赋予默认值. 如字段`age`表默认值为10, 即使你显式设置为0最后也会被GORM设为10提交.
 		// 如果该字段没有上面提到的插入时赋零值的特殊需要, 则字段为非指针类型使用起来会比较方便.
-		FieldCoverable: global.Config.Database.FieldCoverable,
+		FieldCoverable: config.FieldCoverable,
 
 		// 模型结构体字段的数字类型的符号表示是否与表字段的一致, `false`指示都用有符号类型
-		FieldSignable: global.Config.Database.FieldSignable,
+		FieldSignable: config.FieldSignable,
 		// 生成 gorm 标签的字段索引属性
-		FieldWithIndexTag: global.Config.Database.FieldWithIndexTag,
+		FieldWithIndexTag: config.FieldWithIndexTag,
 		// 生成 gorm 标签的字段类型属性
-		FieldWithTypeTag: global.Config.Database.FieldWithTypeTag,
+		FieldWithTypeTag: config.FieldWithTypeTag,
 		// 生成单元测试，默认值 false, 选项: false / true
-		WithUnitTest: global.Config.Database.WithUnitTest,
+		WithUnitTest: config.WithUnitTest,
 		// 生成模型代码包名称。默认值：model
-		ModelPkgPath: global.Config.Database.ModelPkgPath,
+		ModelPkgPath: config.ModelPkgPath,
 	})
 	// 设置目标 db
-	g.UseDB(global.DB)
+	g.UseDB(db)
+
+	// schema名.表名 形式的表名生成的模型名称和文件名中不能包含 .，如 sales.order 生成 SalesOrder 模型和 sales_order.gen.go 文件，
+	// 模型的TableName()仍然返回 sales.order
+	if len(config.Schemas) > 0 {
+		g.WithModelNameStrategy(func(tableName string) string {
+			return db.NamingStrategy.SchemaName(strings.ReplaceAll(tableName, ".", "_"))
+		})
+		g.WithFileNameStrategy(func(tableName string) string {
+			return strings.ToLower(strings.ReplaceAll(tableName, ".", "_"))
+		})
+	}
 
 	// 自定义字段的数据类型
 	// 统一数字类型为int64,兼容protobuf
 	dataMap := map[string]func(columnType gorm.ColumnType) (dataType string){}
-	if global.Config.Database.DataMap != nil {
-		for k, v := range global.Config.Database.DataMap {
+	if config.DataMap != nil {
+		for k, v := range config.DataMap {
 			dataMap[k] = func(columnType gorm.ColumnType) (dataType string) { return v }
 		}
 	}
@@ -76,7 +89,7 @@ func InitGenGenerator() (g *gen.Generator, fieldOpts []gen.ModelOpt) {
 	// 自定义模型结体字段的标签
 	// 将特定字段名的 json 标签修改为驼峰式
 	var jsonField gen.ModelOpt
-	if global.Config.Database.JsonTagFormat {
+	if config.JsonTagFormat {
 		jsonField = gen.FieldJSONTagWithNS(func(columnName string) (tagContent string) {
 			result := Case2Camel(columnName)
 			return result
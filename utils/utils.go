@@ -2,17 +2,15 @@ package utils
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"sort"
 	"strings"
-
-	"github.com/essrt/gentoolplus/global"
 )
 
-// 下划线写法转为驼峰写法
+// 下划线写法转为驼峰写法，schema名.表名 形式的表名同样按 . 分隔，如 sales.order_item 转为 SalesOrderItem
 func Case2Camel(name string) string {
-	words := strings.Split(name, "_")
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '.' })
 	var result string
 	for _, word := range words {
 		result += strings.ToUpper(string(word[0])) + word[1:]
@@ -28,6 +26,15 @@ func ProcessID(str string) string {
 	return str
 }
 
+// 将以逗号分隔的列名转换为结构体字段名，如 company_id,dept_no 转换为 CompanyID,DeptNo
+func FieldNames(columns string) string {
+	names := strings.Split(columns, ",")
+	for i, column := range names {
+		names[i] = Case2Camel(ProcessID(column))
+	}
+	return strings.Join(names, ",")
+}
+
 func ToJson(result interface{}) string {
 	jsonBytes, _ := json.Marshal(result)
 	return string(jsonBytes)
@@ -43,6 +50,16 @@ func ContainsValue(slice []string, value string) bool {
 	return false
 }
 
+// 返回map中按字母顺序排序的key，保证遍历顺序和输出结果稳定
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // 判断切片中是否存在重复的值，并且返回重复的值
 func HasDuplicate(slice []string) (string, bool) {
 	for i := 0; i < len(slice); i++ {
@@ -56,68 +73,72 @@ func HasDuplicate(slice []string) (string, bool) {
 }
 
 /**
- * 将生成的query目录下的gen.go根文件移动到当前目录tmp文件夹下，
- * gen.go文件中保存的是所有表的模型的引用，
- * gen在生成query文件时，只会将ApplyBasic方法参数中的模型写入query中的根文件gen.go中，
- * 而我们在后续调用processTableRelations方法处理关联关系的时候，只处理有关联关系的表，
- * 方法中生成的gen.go中只会有有关联关系的表的模型的引用，因此需要将保存了所有表的模型的引用的gen.go文件
- * 移动到tmp文件夹下，然后再调用processTableRelations方法处理关联关系，处理完关联关系后，
- * 再将tmp文件夹下的gen.go文件移动到query目录下。
+ * 返回query代码和model代码的输出目录（绝对路径），与gen计算model目录的方式一致：
+ * modelPkgPath中包含路径分隔符时直接作为model目录，否则model目录与outPath目录同级
  */
-func MoveGenFile() {
-	workDir, _ := os.Getwd()
-	err := os.MkdirAll(path.Join(workDir, "gen_tool_plus_tmp"), 0777)
+func OutputDirs(outPath, modelPkgPath string) (queryDir, modelDir string, err error) {
+	queryDir, err = filepath.Abs(outPath)
 	if err != nil {
-		fmt.Println("创建文件夹logs失败!", err)
-		return
+		return "", "", err
 	}
-	genFile := path.Join(*global.OutPath, *global.OutFile)
-	if _, err := os.Stat(genFile); err != nil {
-		fmt.Println("moveGenFile:", genFile)
-		fmt.Println("gen.go文件不存在!", err)
-		return
+	if modelPkgPath == "" {
+		modelPkgPath = "model"
 	}
-	fmt.Println("gen.go文件存在:", genFile)
-	os.Rename(genFile, path.Join(workDir, "gen_tool_plus_tmp", *global.OutFile))
-}
-
-/**
- * 将当前目录tmp文件夹下的gen.go文件移动到query目录下
- */
-func MoveGenFileBack() {
-	workDir, _ := os.Getwd()
-	genFile := path.Join(*global.OutPath, *global.OutFile)
-
-	// 删除临时创建的gen_tool_plus_tmp文件夹
-	defer DeleteTmpDir()
-
-	if _, err := os.Stat(genFile); err != nil {
-		fmt.Println("moveGenFileBack:", genFile)
-		fmt.Println("gen.go文件不存在!", err)
-		return
+	if strings.Contains(modelPkgPath, string(os.PathSeparator)) {
+		modelDir, err = filepath.Abs(modelPkgPath)
+		if err != nil {
+			return "", "", err
+		}
+	} else {
+		modelDir = filepath.Join(filepath.Dir(queryDir), modelPkgPath)
 	}
-	err := os.Rename(path.Join(workDir, "gen_tool_plus_tmp", *global.OutFile), genFile)
+	return queryDir, modelDir, nil
+}
 
-	if err != nil {
-		fmt.Println("移动文件失败!", err)
-		return
-	}
+// SchemaDir 返回按schema分别生成代码时schema对应的目录，如 ./dao/query 中billing的query目录为 ./dao/billing/query
+func SchemaDir(dir, schema string) string {
+	return filepath.Join(filepath.Dir(dir), schema, filepath.Base(dir))
 }
 
 /**
-*删除临时创建的tmp文件夹
+ * 根据目录所在Go模块的go.mod文件计算目录的包导入路径，
+ * 不在任何Go模块中时返回空字符串
  */
-func DeleteTmpDir() {
-	workDir, _ := os.Getwd()
-	// 要删除的文件夹路径
-	folderPath := workDir + "/gen_tool_plus_tmp"
-
-	// 删除文件夹
-	err := os.RemoveAll(folderPath)
+func ImportPath(dir string) string {
+	dir, err := filepath.Abs(dir)
 	if err != nil {
-		fmt.Println("删除文件夹出错:", err)
-		return
+		return ""
 	}
+	for root := dir; ; root = filepath.Dir(root) {
+		if content, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			modulePath := ""
+			for _, line := range strings.Split(string(content), "\n") {
+				if line = strings.TrimSpace(line); strings.HasPrefix(line, "module") {
+					modulePath = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+					break
+				}
+			}
+			if modulePath == "" {
+				return ""
+			}
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return ""
+			}
+			if rel == "." {
+				return modulePath
+			}
+			return modulePath + "/" + filepath.ToSlash(rel)
+		}
+		if filepath.Dir(root) == root {
+			return ""
+		}
+	}
+}
 
-	fmt.Println("临时gen_tool_plus_tmp文件夹删除成功")
+// 判断文件是否是gen生成的文件，outFile为query根文件名
+func IsGenFile(name, outFile string) bool {
+	outFile = filepath.Base(outFile)
+	return strings.HasSuffix(name, ".gen.go") || strings.HasSuffix(name, ".gen_test.go") ||
+		name == outFile || name == strings.TrimSuffix(outFile, ".go")+"_test.go"
 }